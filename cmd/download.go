@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bookstairs/bookhunter/cmd/flags"
+)
+
+// addDownloadCommands will add the commands which share the same flags with the given download command.
+func addDownloadCommands(parent, download *cobra.Command) {
+	parent.AddCommand(newDownloadCmd(download, &cobra.Command{
+		Use:   "retry-failed",
+		Short: "Download the failed books again with the same config",
+	}, &flags.RetryFailed))
+	parent.AddCommand(newDownloadCmd(download, &cobra.Command{
+		Use:   "list",
+		Short: "List the books which would be downloaded without downloading them",
+	}, &flags.DryRun))
+}
+
+// newDownloadCmd will create a command which runs the download command with the given toggle enabled.
+func newDownloadCmd(download, cmd *cobra.Command, toggle *bool) *cobra.Command {
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		*toggle = true
+		download.Run(cmd, args)
+	}
+	cmd.Flags().AddFlagSet(download.Flags())
+
+	return cmd
+}
@@ -0,0 +1,47 @@
+package flags
+
+import (
+	"errors"
+
+	"github.com/bookstairs/bookhunter/internal/delivery"
+	"github.com/bookstairs/bookhunter/internal/fetcher"
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+var (
+	// Flags for delivering the books by SMTP.
+
+	SMTPServer  = ""
+	MailTo      []string
+	MailFrom    = ""
+	MailMaxSize = "50MB"
+	MailFormats = []string{
+		string(file.EPUB),
+		string(file.AZW3),
+	}
+)
+
+// newDelivery will validate the delivery flags in the job, the delivery is disabled if no addresses are given.
+func (j *Job) newDelivery() (*delivery.Config, error) {
+	if len(j.MailTo) == 0 {
+		return nil, nil
+	}
+	if j.SMTPServer == "" {
+		return nil, errors.New("the SMTP server is required for delivering the books, use --smtp")
+	}
+
+	server, err := delivery.ParseServer(j.SMTPServer)
+	if err != nil {
+		return nil, err
+	}
+	maxSize, err := fetcher.ParseSize(j.MailMaxSize)
+	if err != nil {
+		return nil, err
+	}
+	formats, err := fetcher.ParseFormats(j.MailFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery.Config{Server: server, From: j.MailFrom, To: j.MailTo, MaxSize: maxSize, Formats: formats}, nil
+}
@@ -0,0 +1,25 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddDownloadFlags(t *testing.T) {
+	f := pflag.NewFlagSet("k12", pflag.ContinueOnError)
+	AddDownloadFlags(f, 0)
+	for _, name := range []string{"download", "ids", "hook", "mail-to", "watch"} {
+		assert.NotNil(t, f.Lookup(name), name)
+	}
+	for _, name := range []string{"format", "extract", "initial", "rename"} {
+		assert.Nil(t, f.Lookup(name), name)
+	}
+
+	f = pflag.NewFlagSet("sobooks", pflag.ContinueOnError)
+	AddDownloadFlags(f, FormatFlags|ExtractFlags|SequenceFlags)
+	for _, name := range []string{"format", "any-format", "extract-max-ratio", "initial", "rename", "watch"} {
+		assert.NotNil(t, f.Lookup(name), name)
+	}
+}
@@ -0,0 +1,54 @@
+package flags
+
+import (
+	"github.com/bookstairs/bookhunter/internal/fetcher"
+)
+
+var (
+	// Flags for filtering the books.
+
+	ExcludeKeywords []string
+	IncludeRegex    = ""
+	ExcludeRegex    = ""
+	IgnoreCase      = false
+	Pinyin          = false
+	MinSize         = ""
+	MaxSize         = ""
+	Authors         []string
+	Publishers      []string
+	Tags            []string
+	PublishedAfter  = ""
+	PublishedBefore = ""
+)
+
+// newFilter will validate the filter flags in the job and create the filter.
+func (j *Job) newFilter() (filter fetcher.Filter, err error) {
+	filter = fetcher.Filter{
+		Keywords:        j.Keywords,
+		ExcludeKeywords: j.ExcludeKeywords,
+		IgnoreCase:      j.IgnoreCase,
+		Pinyin:          j.Pinyin,
+		Authors:         j.Authors,
+		Publishers:      j.Publishers,
+		Tags:            j.Tags,
+	}
+
+	if filter.Include, err = fetcher.ParseRegex(j.IncludeRegex, j.IgnoreCase); err != nil {
+		return
+	}
+	if filter.Exclude, err = fetcher.ParseRegex(j.ExcludeRegex, j.IgnoreCase); err != nil {
+		return
+	}
+	if filter.MinSize, err = fetcher.ParseSize(j.MinSize); err != nil {
+		return
+	}
+	if filter.MaxSize, err = fetcher.ParseSize(j.MaxSize); err != nil {
+		return
+	}
+	if filter.After, err = fetcher.ParseDate(j.PublishedAfter); err != nil {
+		return
+	}
+	filter.Before, err = fetcher.ParseDate(j.PublishedBefore)
+
+	return
+}
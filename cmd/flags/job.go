@@ -0,0 +1,330 @@
+package flags
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/fetcher"
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/hook"
+	"github.com/bookstairs/bookhunter/internal/progress"
+)
+
+// Job is the download config for a fetcher service, the keys are the same as the flag names.
+type Job struct {
+	Name            string            `yaml:"name"`
+	Source          fetcher.Category  `yaml:"source"`
+	Website         string            `yaml:"website"`
+	Proxy           string            `yaml:"proxy"`
+	Formats         []string          `yaml:"format"`
+	Prefer          []string          `yaml:"prefer"`
+	AlsoKeep        []string          `yaml:"also-keep"`
+	AnyFormat       bool              `yaml:"any-format"`
+	Keywords        []string          `yaml:"keyword"`
+	ExcludeKeywords []string          `yaml:"exclude-keyword"`
+	IncludeRegex    string            `yaml:"include-regex"`
+	ExcludeRegex    string            `yaml:"exclude-regex"`
+	IgnoreCase      bool              `yaml:"ignore-case"`
+	Pinyin          bool              `yaml:"pinyin"`
+	MinSize         string            `yaml:"min-size"`
+	MaxSize         string            `yaml:"max-size"`
+	Authors         []string          `yaml:"author"`
+	Publishers      []string          `yaml:"publisher"`
+	Tags            []string          `yaml:"tag"`
+	PublishedAfter  string            `yaml:"published-after"`
+	PublishedBefore string            `yaml:"published-before"`
+	Extract         bool              `yaml:"extract"`
+	ExtractMaxSize  string            `yaml:"extract-max-size"`
+	ExtractMaxFiles int               `yaml:"extract-max-files"`
+	ExtractMaxRatio float64           `yaml:"extract-max-ratio"`
+	ArchiveEncoding string            `yaml:"archive-encoding"`
+	Dedupe          string            `yaml:"dedupe"`
+	OnConflict      string            `yaml:"on-conflict"`
+	DownloadPath    string            `yaml:"download"`
+	InitialBookID   int64             `yaml:"initial"`
+	EndBookID       int64             `yaml:"end"`
+	BookIDs         []string          `yaml:"ids"`
+	BookIDsFile     string            `yaml:"ids-file"`
+	Rename          bool              `yaml:"rename"`
+	NameTemplate    string            `yaml:"name-template"`
+	Metadata        string            `yaml:"metadata"`
+	EmbedMetadata   bool              `yaml:"embed-metadata"`
+	Hooks           []string          `yaml:"hook"`
+	SMTPServer      string            `yaml:"smtp"`
+	MailTo          []string          `yaml:"mail-to"`
+	MailFrom        string            `yaml:"mail-from"`
+	MailMaxSize     string            `yaml:"mail-max-size"`
+	MailFormats     []string          `yaml:"mail-format"`
+	Thread          int               `yaml:"thread"`
+	RateLimit       int               `yaml:"ratelimit"`
+	Retry           int               `yaml:"retry"`
+	SkipError       bool              `yaml:"skip-error"`
+	Storage         string            `yaml:"progress-store"`
+	ReportPath      string            `yaml:"report"`
+	Properties      map[string]string `yaml:"properties"`
+	RetryFailed     bool              `yaml:"-"`
+	DryRun          bool              `yaml:"-"`
+	ListFormat      string            `yaml:"-"`
+	ListOutput      string            `yaml:"-"`
+	Watch           time.Duration     `yaml:"-"`
+}
+
+// NewJob will create the job by the command line arguments.
+func NewJob() *Job {
+	return &Job{
+		Website:         Website,
+		Proxy:           Proxy,
+		Formats:         Formats,
+		Prefer:          Prefer,
+		AlsoKeep:        AlsoKeep,
+		AnyFormat:       AnyFormat,
+		Keywords:        Keywords,
+		ExcludeKeywords: ExcludeKeywords,
+		IncludeRegex:    IncludeRegex,
+		ExcludeRegex:    ExcludeRegex,
+		IgnoreCase:      IgnoreCase,
+		Pinyin:          Pinyin,
+		MinSize:         MinSize,
+		MaxSize:         MaxSize,
+		Authors:         Authors,
+		Publishers:      Publishers,
+		Tags:            Tags,
+		PublishedAfter:  PublishedAfter,
+		PublishedBefore: PublishedBefore,
+		Extract:         Extract,
+		ExtractMaxSize:  ExtractMaxSize,
+		ExtractMaxFiles: ExtractMaxFiles,
+		ExtractMaxRatio: ExtractMaxRatio,
+		ArchiveEncoding: ArchiveEncoding,
+		Dedupe:          Dedupe,
+		OnConflict:      OnConflict,
+		DownloadPath:    DownloadPath,
+		InitialBookID:   InitialBookID,
+		EndBookID:       EndBookID,
+		BookIDs:         BookIDs,
+		BookIDsFile:     BookIDsFile,
+		Rename:          Rename,
+		NameTemplate:    NameTemplate,
+		Metadata:        Metadata,
+		EmbedMetadata:   EmbedMetadata,
+		Hooks:           Hooks,
+		SMTPServer:      SMTPServer,
+		MailTo:          MailTo,
+		MailFrom:        MailFrom,
+		MailMaxSize:     MailMaxSize,
+		MailFormats:     MailFormats,
+		Thread:          Thread,
+		RateLimit:       RateLimit,
+		Retry:           Retry,
+		SkipError:       SkipError,
+		Storage:         Storage,
+		ReportPath:      ReportPath,
+		RetryFailed:     RetryFailed,
+		DryRun:          DryRun,
+		ListFormat:      ListFormat,
+		ListOutput:      ListOutput,
+		Watch:           Watch,
+	}
+}
+
+// NewConfig will validate the job and create the fetcher config.
+func (j *Job) NewConfig() (*fetcher.Config, error) {
+	cc, err := client.NewConfig(j.Website, j.Proxy, ConfigRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	fs, err := fetcher.ParseFormats(j.Formats)
+	if err != nil {
+		return nil, err
+	}
+	prefer, err := fetcher.ParseFormats(j.Prefer)
+	if err != nil {
+		return nil, err
+	}
+	alsoKeep, err := fetcher.ParseFormats(j.AlsoKeep)
+	if err != nil {
+		return nil, err
+	}
+	// The preferred formats should be downloadable.
+	for _, format := range append(append([]file.Format{}, prefer...), alsoKeep...) {
+		if !slices.Contains(fs, format) {
+			fs = append(fs, format)
+		}
+	}
+
+	dedupe, err := file.ParseDedupe(j.Dedupe)
+	if err != nil {
+		return nil, err
+	}
+	conflict, err := file.ParseConflict(j.OnConflict)
+	if err != nil {
+		return nil, err
+	}
+
+	extractMaxSize, err := fetcher.ParseSize(j.ExtractMaxSize)
+	if err != nil {
+		return nil, err
+	}
+	if j.ExtractMaxFiles < 0 || j.ExtractMaxRatio < 0 {
+		return nil, fmt.Errorf("the extraction limits shouldn't be negative")
+	}
+	archiveEncoding, err := file.ParseArchiveEncoding(j.ArchiveEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	nameTemplate, err := file.ParseNameTemplate(j.NameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := file.ParseMetadataFormat(j.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := hook.Parse(j.Hooks)
+	if err != nil {
+		return nil, err
+	}
+	mail, err := j.newDelivery()
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := progress.ParseStorage(j.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := j.newFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	listFormat, err := fetcher.ParseListFormat(j.ListFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := j.bookIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) != 0 && j.Watch > 0 {
+		return nil, fmt.Errorf("the --watch flag couldn't be used with --ids or --ids-file, the given books are downloaded once")
+	}
+	if j.EndBookID > 0 && j.EndBookID < j.InitialBookID {
+		return nil, fmt.Errorf("the end book id %d should be larger than the initial book id %d", j.EndBookID, j.InitialBookID)
+	}
+
+	limits := file.ExtractLimits{MaxSize: extractMaxSize, MaxFiles: j.ExtractMaxFiles, MaxRatio: j.ExtractMaxRatio}
+
+	return &fetcher.Config{
+		Config:        cc,
+		Category:      j.Source,
+		Formats:       fs,
+		Prefer:        prefer,
+		AlsoKeep:      alsoKeep,
+		AnyFormat:     j.AnyFormat,
+		Filter:        filter,
+		Extract:       j.Extract,
+		ExtractLimits: limits,
+		Dedupe:        dedupe,
+		Conflict:      conflict,
+		DownloadPath:  j.DownloadPath,
+		InitialBookID: j.InitialBookID,
+		EndBookID:     j.EndBookID,
+		BookIDs:       ids,
+		Rename:        j.Rename,
+		NameTemplate:  nameTemplate,
+		Metadata:      metadata,
+		EmbedMetadata: j.EmbedMetadata,
+		Hooks:         hooks,
+		Delivery:      mail,
+		Thread:        j.Thread,
+		RateLimit:     j.RateLimit,
+		Properties:    j.Properties,
+		Retry:         j.Retry,
+		SkipError:     j.SkipError,
+		Storage:       storage,
+		RetryFailed:   j.RetryFailed,
+		DryRun:        j.DryRun,
+		ListFormat:    listFormat,
+		ListOutput:    j.ListOutput,
+		ReportPath:    j.ReportPath,
+		Watch:         j.Watch,
+
+		ArchiveEncoding: archiveEncoding,
+	}, nil
+}
+
+// bookIDs will merge the book ids from the job and the ids file.
+func (j *Job) bookIDs() ([]int64, error) {
+	values := append([]string{}, j.BookIDs...)
+	if j.BookIDsFile != "" {
+		ids, err := progress.ReadBookIDs(j.BookIDsFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			values = append(values, strconv.FormatInt(id, 10))
+		}
+	}
+
+	return progress.ParseBookIDs(values...)
+}
+
+// Jobs is the batch download config for the run command.
+type Jobs struct {
+	Concurrency int    `yaml:"concurrency"` // The max number of the running jobs, the jobs run one by one by default.
+	RateLimit   int    `yaml:"ratelimit"`   // The shared requests per minute for the jobs which download from the same host.
+	Jobs        []*Job `yaml:"jobs"`
+}
+
+// UnmarshalYAML will use the command line arguments as the default values of the job.
+func (j *Job) UnmarshalYAML(value *yaml.Node) error {
+	type plain Job
+	job := NewJob()
+	if err := value.Decode((*plain)(job)); err != nil {
+		return err
+	}
+	*j = *job
+
+	return nil
+}
+
+// LoadJobs will read the jobs from the yaml file.
+func LoadJobs(path string) (*Jobs, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := &Jobs{Concurrency: 1, RateLimit: RateLimit}
+	if err := yaml.Unmarshal(content, jobs); err != nil {
+		return nil, fmt.Errorf("invalid jobs file %s: %w", path, err)
+	}
+	if len(jobs.Jobs) == 0 {
+		return nil, fmt.Errorf("no jobs are defined in %s", path)
+	}
+	if jobs.Concurrency < 1 || jobs.RateLimit < 1 {
+		return nil, fmt.Errorf("the concurrency and ratelimit in %s should be positive", path)
+	}
+
+	for i, job := range jobs.Jobs {
+		if job.Source == "" {
+			return nil, fmt.Errorf("the source of the job %d in %s is required", i+1, path)
+		}
+		if job.Name == "" {
+			job.Name = fmt.Sprintf("%s-%d", job.Source, i+1)
+		}
+	}
+
+	return jobs, nil
+}
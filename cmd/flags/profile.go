@@ -0,0 +1,191 @@
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bookstairs/bookhunter/internal/client"
+)
+
+var (
+	Profile     = "" // The profile name in the config file.
+	ProfileFile = "" // The config file which defines the profiles.
+
+	// The config files which would be searched in the config root.
+	defaultProfileFiles = []string{"config.yaml", "config.yml", "config.toml"}
+)
+
+var ErrProfileFile = errors.New("couldn't find the config file for the profiles")
+
+// profileProperties is the profile key for the driver properties.
+const profileProperties = "properties"
+
+// driverFlags is the flag name of the driver properties, the flags given in the command line wouldn't be overridden.
+var driverFlags = map[string]string{
+	"driver":          "driver",
+	"refreshToken":    "refresh-token",
+	"telecomUsername": "telecom-username",
+	"telecomPassword": "telecom-password",
+}
+
+// Profiles is the config file content, every profile is a named set of flag values.
+// The keys in the profile are the flag names, the driver properties are defined in the properties key.
+//
+//	profiles:
+//	  home-nas:
+//	    website: https://talebook.example.com
+//	    username: bookhunter
+//	    format: [epub, pdf]
+//	    thread: 4
+//	    download: /mnt/books
+//	    properties:
+//	      driver: aliyun
+//	      refreshToken: token
+type Profiles struct {
+	Profiles map[string]map[string]any `yaml:"profiles" toml:"profiles"`
+}
+
+// LoadProfile will read the named profile from the config file. The config file will be searched
+// in the config root if the path is empty.
+func LoadProfile(path, name string) (map[string]any, error) {
+	if path == "" {
+		var err error
+		if path, err = findProfileFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := new(Profiles)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(content, profiles)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, profiles)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s, it should be yaml or toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	profile, ok := profiles.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no such profile %s in the config file %s", name, path)
+	}
+	return profile, nil
+}
+
+func findProfileFile() (string, error) {
+	root := ConfigRoot
+	if root == "" {
+		var err error
+		if root, err = client.DefaultConfigRoot(); err != nil {
+			return "", err
+		}
+	}
+
+	for _, name := range defaultProfileFiles {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%w in %s, it should be one of %v", ErrProfileFile, root, defaultProfileFiles)
+}
+
+// ApplyProfile will set the flags by the profile values. The flags given in the command line
+// have the higher priority and wouldn't be overridden.
+func ApplyProfile(fs *pflag.FlagSet, profile map[string]any) error {
+	keys := make([]string, 0, len(profile))
+	for key := range profile {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := profile[key]
+		if key == profileProperties {
+			if err := applyDriverProperties(fs, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		flag := fs.Lookup(key)
+		if flag == nil {
+			return fmt.Errorf("invalid profile key %s, it isn't a flag of this command", key)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		if err := setFlag(fs, flag, value); err != nil {
+			return fmt.Errorf("invalid profile value for %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func setFlag(fs *pflag.FlagSet, flag *pflag.Flag, value any) error {
+	values, ok := value.([]any)
+	if !ok {
+		return fs.Set(flag.Name, fmt.Sprint(value))
+	}
+
+	slice, ok := flag.Value.(pflag.SliceValue)
+	if !ok {
+		return fmt.Errorf("the flag %s doesn't accept a list", flag.Name)
+	}
+	items := make([]string, 0, len(values))
+	for _, v := range values {
+		items = append(items, fmt.Sprint(v))
+	}
+	if err := slice.Replace(items); err != nil {
+		return err
+	}
+	flag.Changed = true
+
+	return nil
+}
+
+func applyDriverProperties(fs *pflag.FlagSet, value any) error {
+	properties, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("the profile %s should be a map", profileProperties)
+	}
+
+	for key, v := range properties {
+		if flag := fs.Lookup(driverFlags[key]); flag != nil && flag.Changed {
+			continue
+		}
+		s := fmt.Sprint(v)
+		switch key {
+		case "driver":
+			Driver = s
+		case "refreshToken":
+			RefreshToken = s
+		case "telecomUsername":
+			TelecomUsername = s
+		case "telecomPassword":
+			TelecomPassword = s
+		default:
+			return fmt.Errorf("invalid driver property %s in the profile", key)
+		}
+	}
+
+	return nil
+}
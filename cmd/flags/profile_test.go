@@ -0,0 +1,105 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProfileFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringP("website", "w", "", "")
+	fs.StringSliceP("format", "f", []string{"epub"}, "")
+	fs.IntP("thread", "t", 1, "")
+	fs.StringP("download", "d", ".", "")
+	return fs
+}
+
+func TestLoadProfile(t *testing.T) {
+	defer func(driver string) { Driver = driver }(Driver)
+
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte(`
+profiles:
+  home-nas:
+    website: https://talebook.example.com
+    format: [epub, pdf]
+    thread: 4
+    download: /mnt/books
+    properties:
+      driver: aliyun
+`), 0o644))
+	tomlFile := filepath.Join(dir, "config.toml")
+	assert.NoError(t, os.WriteFile(tomlFile, []byte(`
+[profiles.home-nas]
+website = "https://talebook.example.com"
+format = ["epub", "pdf"]
+thread = 4
+download = "/mnt/books"
+
+[profiles.home-nas.properties]
+driver = "aliyun"
+`), 0o644))
+
+	for _, path := range []string{yamlFile, tomlFile} {
+		profile, err := LoadProfile(path, "home-nas")
+		assert.NoError(t, err)
+
+		fs := newProfileFlags()
+		assert.NoError(t, fs.Parse([]string{"-t", "8"}))
+		assert.NoError(t, ApplyProfile(fs, profile))
+
+		website, _ := fs.GetString("website")
+		formats, _ := fs.GetStringSlice("format")
+		thread, _ := fs.GetInt("thread")
+		download, _ := fs.GetString("download")
+		assert.Equal(t, "https://talebook.example.com", website)
+		assert.Equal(t, []string{"epub", "pdf"}, formats)
+		assert.Equal(t, 8, thread, "the command line flags should override the profile")
+		assert.Equal(t, "/mnt/books", download)
+		assert.Equal(t, "aliyun", Driver)
+		assert.True(t, fs.Lookup("website").Changed)
+	}
+
+	_, err := LoadProfile(yamlFile, "office")
+	assert.Error(t, err)
+}
+
+func TestApplyProfile_DriverFlags(t *testing.T) {
+	defer func(driver, token string) { Driver, RefreshToken = driver, token }(Driver, RefreshToken)
+
+	fs := newProfileFlags()
+	fs.StringVar(&RefreshToken, "refresh-token", "", "")
+	assert.NoError(t, fs.Parse([]string{"--refresh-token", "flag-token"}))
+	assert.NoError(t, ApplyProfile(fs, map[string]any{
+		"properties": map[string]any{"driver": "aliyun", "refreshToken": "profile-token"},
+	}))
+
+	assert.Equal(t, "aliyun", Driver)
+	assert.Equal(t, "flag-token", RefreshToken, "the command line flags should override the driver properties")
+}
+
+func TestApplyProfile_Invalid(t *testing.T) {
+	assert.Error(t, ApplyProfile(newProfileFlags(), map[string]any{"unknown": "value"}))
+	assert.Error(t, ApplyProfile(newProfileFlags(), map[string]any{"thread": "many"}))
+	assert.Error(t, ApplyProfile(newProfileFlags(), map[string]any{"website": []any{"a", "b"}}))
+	assert.Error(t, ApplyProfile(newProfileFlags(), map[string]any{"properties": map[string]any{"token": "a"}}))
+}
+
+func TestFindProfileFile(t *testing.T) {
+	ConfigRoot = t.TempDir()
+	defer func() { ConfigRoot = "" }()
+
+	_, err := findProfileFile()
+	assert.ErrorIs(t, err, ErrProfileFile)
+
+	path := filepath.Join(ConfigRoot, "config.toml")
+	assert.NoError(t, os.WriteFile(path, nil, 0o644))
+	found, err := findProfileFile()
+	assert.NoError(t, err)
+	assert.Equal(t, path, found)
+}
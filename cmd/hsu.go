@@ -14,21 +14,12 @@ var hsuCmd = &cobra.Command{
 	Use:   "hsu",
 	Short: "A tool for downloading book from hsu.life",
 	Run: func(cmd *cobra.Command, args []string) {
-		log.NewPrinter().
+		flags.PrintDownloadInfo(log.NewPrinter().
 			Title("hsu.life Download Information").
 			Head(log.DefaultHead...).
 			Row("Username", flags.Username).
-			Row("Password", flags.HideSensitive(flags.Password)).
-			Row("Config Path", flags.ConfigRoot).
-			Row("Proxy", flags.Proxy).
-			Row("Formats", flags.Formats).
-			Row("Download Path", flags.DownloadPath).
-			Row("Initial ID", flags.InitialBookID).
-			Row("Rename File", flags.Rename).
-			Row("Thread", flags.Thread).
-			Row("Keywords", flags.Keywords).
-			Row("Thread Limit (req/min)", flags.RateLimit).
-			Print()
+			Row("Password", flags.HideSensitive(flags.Password)),
+			flags.FormatFlags|flags.SequenceFlags)
 
 		flags.Website = hsuWebsite
 
@@ -40,7 +31,7 @@ var hsuCmd = &cobra.Command{
 		log.Exit(err)
 
 		// Start downloading the books.
-		err = f.Download()
+		err = f.Download(cmd.Context())
 		log.Exit(err)
 
 		// Finished all the tasks.
@@ -57,14 +48,11 @@ func init() {
 	f.StringVarP(&flags.Password, "password", "p", flags.Password, "The hsu.life password")
 
 	// Common download flags.
-	f.StringSliceVarP(&flags.Formats, "format", "f", flags.Formats, "The file formats you want to download")
-	f.StringVarP(&flags.DownloadPath, "download", "d", flags.DownloadPath, "The book directory you want to use")
-	f.Int64VarP(&flags.InitialBookID, "initial", "i", flags.InitialBookID, "The book id you want to start download")
-	f.BoolVarP(&flags.Rename, "rename", "r", flags.Rename, "Rename the book file by book id")
-	f.IntVarP(&flags.Thread, "thread", "t", flags.Thread, "The number of download thead")
-	f.IntVar(&flags.RateLimit, "ratelimit", flags.RateLimit, "The allowed requests per minutes for every thread")
+	flags.AddDownloadFlags(f, flags.FormatFlags|flags.SequenceFlags)
 
 	// Mark some flags as required.
 	_ = hsuCmd.MarkFlagRequired("username")
 	_ = hsuCmd.MarkFlagRequired("password")
+
+	addDownloadCommands(hsuCmd, hsuCmd)
 }
@@ -15,22 +15,15 @@ var k12Cmd = &cobra.Command{
 	Short: "A tool for downloading textbooks from www.zxx.edu.cn",
 	Run: func(cmd *cobra.Command, args []string) {
 		// Print download configuration.
-		log.NewPrinter().
+		flags.PrintDownloadInfo(log.NewPrinter().
 			Title("Textbook Download Information").
-			Head(log.DefaultHead...).
-			Row("Config Path", flags.ConfigRoot).
-			Row("Proxy", flags.Proxy).
-			Row("Download Path", flags.DownloadPath).
-			Row("Thread", flags.Thread).
-			Row("Thread Limit (req/min)", flags.RateLimit).
-			Row("Keywords", flags.Keywords).
-			Print()
+			Head(log.DefaultHead...), 0)
 
 		flags.Website = k12Website
 		f, err := flags.NewFetcher(fetcher.K12, map[string]string{})
 		log.Exit(err)
 
-		err = f.Download()
+		err = f.Download(cmd.Context())
 		log.Exit(err)
 
 		// Finished all the tasks.
@@ -41,7 +34,8 @@ var k12Cmd = &cobra.Command{
 func init() {
 	f := k12Cmd.Flags()
 
-	f.StringVarP(&flags.DownloadPath, "download", "d", flags.DownloadPath, "The book directory you want to use")
-	f.IntVarP(&flags.Thread, "thread", "t", flags.Thread, "The number of download thead")
-	f.IntVar(&flags.RateLimit, "ratelimit", flags.RateLimit, "The allowed requests per minutes for every thread")
+	// Common download flags.
+	flags.AddDownloadFlags(f, 0)
+
+	addDownloadCommands(k12Cmd, k12Cmd)
 }
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -18,12 +21,24 @@ var rootCmd = &cobra.Command{
 1. Self-hosted talebook websites
 2. https://www.sanqiu.mobi
 3. Telegram channel`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Load the flags from the profile, the flags in the command line have the higher priority.
+		if flags.Profile == "" {
+			return
+		}
+		profile, err := flags.LoadProfile(flags.ProfileFile, flags.Profile)
+		log.Exit(err)
+		log.Exit(flags.ApplyProfile(cmd.Flags(), profile))
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// Cancel the running commands on Ctrl-C for a graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -36,19 +51,48 @@ func init() {
 	rootCmd.AddCommand(sobooksCmd)
 	rootCmd.AddCommand(k12Cmd)
 	rootCmd.AddCommand(hsuCmd)
+	rootCmd.AddCommand(runCmd)
 
 	// Tool commands.
 	rootCmd.AddCommand(aliyunCmd)
+	rootCmd.AddCommand(verifyCmd)
 	rootCmd.AddCommand(versionCmd)
 
 	persistentFlags := rootCmd.PersistentFlags()
 
 	// Common flags.
 	persistentFlags.StringVarP(&flags.ConfigRoot, "config", "c", flags.ConfigRoot, "The config path for bookhunter")
+	persistentFlags.StringVar(&flags.Profile, "profile", flags.Profile, "The profile name in the config file")
+	persistentFlags.StringVar(&flags.ProfileFile, "profile-file", flags.ProfileFile,
+		"The config file for the profiles, the config.yaml or config.toml in the config path will be used by default")
 	persistentFlags.StringVar(&flags.Proxy, "proxy", flags.Proxy, "The request proxy")
 	persistentFlags.IntVarP(&flags.Retry, "retry", "", flags.Retry, "The retry times for a failed download")
 	persistentFlags.BoolVarP(&flags.SkipError, "skip-error", "s", flags.SkipError,
 		"Continue to download the next book if the current book download failed")
+	persistentFlags.StringVar(&flags.Storage, "progress-store", flags.Storage,
+		"The storage for the download progress: bitset or bolt, the bolt storage will import the bitset progress")
+	persistentFlags.StringVar(&flags.ReportPath, "report", flags.ReportPath, "The file for saving the JSON report of the download")
 	persistentFlags.StringSliceVarP(&flags.Keywords, "keyword", "k", flags.Keywords, "The keywords for books")
+
+	// Filter flags.
+	persistentFlags.StringSliceVar(&flags.ExcludeKeywords, "exclude-keyword", flags.ExcludeKeywords,
+		"Skip the books which contain the keywords")
+	persistentFlags.StringVar(&flags.IncludeRegex, "include-regex", flags.IncludeRegex,
+		"The regular expression that the file name should match")
+	persistentFlags.StringVar(&flags.ExcludeRegex, "exclude-regex", flags.ExcludeRegex,
+		"Skip the books which file name matches the regular expression")
+	persistentFlags.BoolVar(&flags.IgnoreCase, "ignore-case", flags.IgnoreCase,
+		"Match the keywords, regular expressions and metadata in case-insensitive")
+	persistentFlags.BoolVar(&flags.Pinyin, "pinyin", flags.Pinyin,
+		"Match the keywords and metadata by the pinyin of the Chinese, such as sanguo or sg for 三国")
+	persistentFlags.StringVar(&flags.MinSize, "min-size", flags.MinSize, "The minimal file size, such as 512KB")
+	persistentFlags.StringVar(&flags.MaxSize, "max-size", flags.MaxSize, "The maximal file size, such as 100MB")
+	persistentFlags.StringSliceVar(&flags.Authors, "author", flags.Authors, "The authors of the books")
+	persistentFlags.StringSliceVar(&flags.Publishers, "publisher", flags.Publishers, "The publishers of the books")
+	persistentFlags.StringSliceVar(&flags.Tags, "tag", flags.Tags, "The tags of the books")
+	persistentFlags.StringVar(&flags.PublishedAfter, "published-after", flags.PublishedAfter,
+		"Only download the books published after the date, such as 2020-01-01")
+	persistentFlags.StringVar(&flags.PublishedBefore, "published-before", flags.PublishedBefore,
+		"Only download the books published before the date, such as 2020-01-01")
 	persistentFlags.BoolVar(&log.EnableDebug, "verbose", false, "Print all the logs for debugging")
 }
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/ratelimit"
+
+	"github.com/bookstairs/bookhunter/cmd/flags"
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/fetcher"
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+// jobResult is the execution result of a job.
+type jobResult struct {
+	job     *flags.Job
+	report  *fetcher.Report
+	elapsed time.Duration
+	err     error
+}
+
+// runCmd will run the download jobs defined in a yaml file.
+var runCmd = &cobra.Command{
+	Use:   "run jobs.yaml",
+	Short: "Run the download jobs for multiple sources in the given yaml file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		jobs, err := flags.LoadJobs(args[0])
+		log.Exit(err)
+
+		// Validate all the jobs before downloading.
+		configs := make([]*fetcher.Config, 0, len(jobs.Jobs))
+		limits := map[string]ratelimit.Limiter{}
+		for _, job := range jobs.Jobs {
+			c, err := newJobConfig(job)
+			if err != nil {
+				log.Exit(fmt.Errorf("invalid job %s: %w", job.Name, err))
+			}
+
+			// The jobs which download from the same host share the same ratelimit.
+			limit, ok := limits[c.Host]
+			if !ok {
+				limit = ratelimit.New(jobs.RateLimit, ratelimit.Per(time.Minute))
+				limits[c.Host] = limit
+			}
+			c.HostLimit = limit
+
+			configs = append(configs, c)
+		}
+
+		log.NewPrinter().
+			Title("Batch Download Information").
+			Head(log.DefaultHead...).
+			Row("Config Path", flags.ConfigRoot).
+			Row("Jobs", len(jobs.Jobs)).
+			Row("Concurrency", jobs.Concurrency).
+			Row("Host Limit (req/min)", jobs.RateLimit).
+			Print()
+
+		results := runJobs(cmd.Context(), jobs, configs)
+
+		// Print the results of the jobs.
+		printer := log.NewPrinter().
+			Title("Job Results").
+			Head("Job", "Source", "Downloaded", "Skipped", "Failed", "Elapsed", "Error").
+			AllowZeroValue()
+		var failed []string
+		for _, result := range results {
+			var downloaded, skipped, fails int
+			if result.report != nil {
+				downloaded, skipped, fails = result.report.Downloaded, result.report.Skipped, result.report.Failed
+			}
+			reason := ""
+			if result.err != nil {
+				reason = result.err.Error()
+				failed = append(failed, result.job.Name)
+			}
+			printer.Row(result.job.Name, result.job.Source, downloaded, skipped, fails,
+				result.elapsed.Round(time.Second).String(), reason)
+		}
+		printer.Print()
+
+		if len(failed) > 0 {
+			log.Exit(fmt.Errorf("%d jobs failed: %s", len(failed), strings.Join(failed, ", ")))
+		}
+		log.Info("Successfully finished all the jobs.")
+	},
+}
+
+// runJobs will run the jobs with the concurrency limit and return the results in the job order.
+func runJobs(ctx context.Context, jobs *flags.Jobs, configs []*fetcher.Config) []*jobResult {
+	results := make([]*jobResult, len(configs))
+	tokens := make(chan struct{}, jobs.Concurrency)
+
+	var wait sync.WaitGroup
+	for i, c := range configs {
+		results[i] = &jobResult{job: jobs.Jobs[i]}
+		if ctx.Err() != nil {
+			results[i].err = fetcher.ErrDownloadInterrupted
+			continue
+		}
+
+		tokens <- struct{}{}
+		wait.Add(1)
+		go func(result *jobResult, c *fetcher.Config) {
+			defer func() {
+				<-tokens
+				wait.Done()
+			}()
+
+			log.Infof("Start the job %s.", result.job.Name)
+			start := time.Now()
+			f, err := fetcher.New(c)
+			if err == nil {
+				err = f.Download(ctx)
+				result.report = f.Report()
+			}
+			result.elapsed = time.Since(start)
+			result.err = err
+			log.Infof("Finished the job %s in %s.", result.job.Name, result.elapsed.Round(time.Second))
+		}(results[i], c)
+	}
+	wait.Wait()
+
+	return results
+}
+
+// newJobConfig will fill the source related config and create the fetcher config.
+func newJobConfig(job *flags.Job) (*fetcher.Config, error) {
+	properties := map[string]string{}
+	for k, v := range job.Properties {
+		properties[k] = v
+	}
+
+	switch job.Source {
+	case fetcher.Talebook:
+		if job.Website == "" {
+			return nil, errors.New("the website is required for talebook")
+		}
+	case fetcher.SoBooks:
+		job.Website = sobooksWebsite
+		if job.InitialBookID < lowestSobooksBookID {
+			job.InitialBookID = lowestSobooksBookID
+		}
+		for k, v := range flags.NewDriverProperties() {
+			if _, ok := properties[k]; !ok {
+				properties[k] = v
+			}
+		}
+		properties["driver"] = string(driver.LANZOU)
+		if properties["code"] == "" {
+			properties["code"] = flags.SoBooksCode
+		}
+	case fetcher.Telegram:
+		channelID := properties["channelID"]
+		if channelID == "" {
+			return nil, errors.New("the channelID property is required for telegram")
+		}
+		job.Website = channelID
+		properties["channelID"] = strings.TrimPrefix(channelID, "https://t.me/")
+	case fetcher.K12:
+		job.Website = k12Website
+	case fetcher.Hsu:
+		job.Website = hsuWebsite
+	default:
+		return nil, fmt.Errorf("no such source %s", job.Source)
+	}
+	job.Properties = properties
+
+	return job.NewConfig()
+}
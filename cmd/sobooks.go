@@ -25,21 +25,11 @@ var sobooksCmd = &cobra.Command{
 		}
 
 		// Print download configuration.
-		log.NewPrinter().
+		flags.PrintDownloadInfo(log.NewPrinter().
 			Title("SoBooks Download Information").
 			Head(log.DefaultHead...).
-			Row("SoBooks Code", flags.SoBooksCode).
-			Row("Config Path", flags.ConfigRoot).
-			Row("Proxy", flags.Proxy).
-			Row("Formats", flags.Formats).
-			Row("Extract Archive", flags.Extract).
-			Row("Download Path", flags.DownloadPath).
-			Row("Initial ID", flags.InitialBookID).
-			Row("Rename File", flags.Rename).
-			Row("Thread", flags.Thread).
-			Row("Keywords", flags.Keywords).
-			Row("Thread Limit (req/min)", flags.RateLimit).
-			Print()
+			Row("SoBooks Code", flags.SoBooksCode),
+			flags.FormatFlags|flags.ExtractFlags|flags.SequenceFlags)
 
 		// Set the domain for using in the client.Client.
 		flags.Website = sobooksWebsite
@@ -52,7 +42,7 @@ var sobooksCmd = &cobra.Command{
 		log.Exit(err)
 
 		// Wait all the threads have finished.
-		err = f.Download()
+		err = f.Download(cmd.Context())
 		log.Exit(err)
 
 		// Finished all the tasks.
@@ -63,17 +53,13 @@ var sobooksCmd = &cobra.Command{
 func init() {
 	f := sobooksCmd.Flags()
 
-	// Common download flags.
-	f.StringSliceVarP(&flags.Formats, "format", "f", flags.Formats, "The file formats you want to download")
-	f.BoolVarP(&flags.Extract, "extract", "e", flags.Extract, "Extract the archive file for filtering")
-	f.StringVarP(&flags.DownloadPath, "download", "d", flags.DownloadPath, "The book directory you want to use")
-	f.Int64VarP(&flags.InitialBookID, "initial", "i", flags.InitialBookID, "The book id you want to start download")
-	f.BoolVarP(&flags.Rename, "rename", "r", flags.Rename, "Rename the book file by book id")
-	f.IntVarP(&flags.Thread, "thread", "t", flags.Thread, "The number of download thead")
-	f.IntVar(&flags.RateLimit, "ratelimit", flags.RateLimit, "The allowed requests per minutes for every thread")
-
 	// SoBooks books flags.
 	f.StringVar(&flags.SoBooksCode, "code", flags.SoBooksCode, "The secret code for SoBooks")
 
+	// Common download flags.
+	flags.AddDownloadFlags(f, flags.FormatFlags|flags.ExtractFlags|flags.SequenceFlags)
+
 	_ = sobooksCmd.MarkFlagRequired("code")
+
+	addDownloadCommands(sobooksCmd, sobooksCmd)
 }
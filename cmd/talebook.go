@@ -24,22 +24,13 @@ var talebookDownloadCmd = &cobra.Command{
 	Short: "Download the books from talebook",
 	Run: func(cmd *cobra.Command, args []string) {
 		// Print download configuration.
-		log.NewPrinter().
+		flags.PrintDownloadInfo(log.NewPrinter().
 			Title("Talebook Download Information").
 			Head(log.DefaultHead...).
 			Row("Website", flags.Website).
 			Row("Username", flags.HideSensitive(flags.Username)).
-			Row("Password", flags.HideSensitive(flags.Password)).
-			Row("Config Path", flags.ConfigRoot).
-			Row("Proxy", flags.Proxy).
-			Row("Formats", flags.Formats).
-			Row("Download Path", flags.DownloadPath).
-			Row("Initial ID", flags.InitialBookID).
-			Row("Rename File", flags.Rename).
-			Row("Thread", flags.Thread).
-			Row("Keywords", flags.Keywords).
-			Row("Thread Limit (req/min)", flags.RateLimit).
-			Print()
+			Row("Password", flags.HideSensitive(flags.Password)),
+			flags.FormatFlags|flags.SequenceFlags)
 
 		// Create the fetcher.
 		f, err := flags.NewFetcher(fetcher.Talebook, map[string]string{
@@ -49,7 +40,7 @@ var talebookDownloadCmd = &cobra.Command{
 		log.Exit(err)
 
 		// Start downloading the books.
-		err = f.Download()
+		err = f.Download(cmd.Context())
 		log.Exit(err)
 
 		// Finished all the tasks.
@@ -118,18 +109,15 @@ func init() {
 	f.StringVarP(&flags.Website, "website", "w", flags.Website, "The talebook link")
 
 	// Common download flags.
-	f.StringSliceVarP(&flags.Formats, "format", "f", flags.Formats, "The file formats you want to download")
-	f.StringVarP(&flags.DownloadPath, "download", "d", flags.DownloadPath, "The book directory you want to use")
-	f.Int64VarP(&flags.InitialBookID, "initial", "i", flags.InitialBookID, "The book id you want to start download")
-	f.BoolVarP(&flags.Rename, "rename", "r", flags.Rename, "Rename the book file by book id")
-	f.IntVarP(&flags.Thread, "thread", "t", flags.Thread, "The number of download thead")
-	f.IntVar(&flags.RateLimit, "ratelimit", flags.RateLimit, "The allowed requests per minutes for every thread")
+	flags.AddDownloadFlags(f, flags.FormatFlags|flags.SequenceFlags)
 
 	// Mark some flags as required.
 	_ = talebookDownloadCmd.MarkFlagRequired("website")
 
 	talebookCmd.AddCommand(talebookDownloadCmd)
 
+	addDownloadCommands(talebookCmd, talebookDownloadCmd)
+
 	/// Add the register command.
 
 	f = talebookRegisterCmd.Flags()
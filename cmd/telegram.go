@@ -21,24 +21,14 @@ var telegramCmd = &cobra.Command{
 		flags.ChannelID = strings.TrimPrefix(flags.ChannelID, "https://t.me/")
 
 		// Print download configuration.
-		log.NewPrinter().
+		flags.PrintDownloadInfo(log.NewPrinter().
 			Title("Telegram Download Information").
 			Head(log.DefaultHead...).
-			Row("Config Path", flags.ConfigRoot).
-			Row("Proxy", flags.Proxy).
 			Row("Channel ID", flags.ChannelID).
 			Row("Mobile", flags.HideSensitive(flags.Mobile)).
 			Row("AppID", flags.HideSensitive(strconv.FormatInt(flags.AppID, 10))).
-			Row("AppHash", flags.HideSensitive(flags.AppHash)).
-			Row("Formats", flags.Formats).
-			Row("Extract Archive", flags.Extract).
-			Row("Download Path", flags.DownloadPath).
-			Row("Initial ID", flags.InitialBookID).
-			Row("Rename File", flags.Rename).
-			Row("Thread", flags.Thread).
-			Row("Keywords", flags.Keywords).
-			Row("Thread Limit (req/min)", flags.RateLimit).
-			Print()
+			Row("AppHash", flags.HideSensitive(flags.AppHash)),
+			flags.FormatFlags|flags.ExtractFlags|flags.SequenceFlags)
 
 		// Create the fetcher.
 		f, err := flags.NewFetcher(fetcher.Telegram, map[string]string{
@@ -51,7 +41,7 @@ var telegramCmd = &cobra.Command{
 		log.Exit(err)
 
 		// Wait all the threads have finished.
-		err = f.Download()
+		err = f.Download(cmd.Context())
 		log.Exit(err)
 
 		// Finished all the tasks.
@@ -70,16 +60,12 @@ func init() {
 	f.StringVar(&flags.AppHash, "appHash", flags.AppHash, "The app hash for telegram")
 
 	// Common download flags.
-	f.StringSliceVarP(&flags.Formats, "format", "f", flags.Formats, "The file formats you want to download")
-	f.BoolVarP(&flags.Extract, "extract", "e", flags.Extract, "Extract the archive file for filtering")
-	f.StringVarP(&flags.DownloadPath, "download", "d", flags.DownloadPath, "The book directory you want to use")
-	f.Int64VarP(&flags.InitialBookID, "initial", "i", flags.InitialBookID, "The book id you want to start download")
-	f.BoolVarP(&flags.Rename, "rename", "r", flags.Rename, "Rename the book file by book id")
-	f.IntVarP(&flags.Thread, "thread", "t", flags.Thread, "The number of download thead")
-	f.IntVar(&flags.RateLimit, "ratelimit", flags.RateLimit, "The allowed requests per minutes for every thread")
+	flags.AddDownloadFlags(f, flags.FormatFlags|flags.ExtractFlags|flags.SequenceFlags)
 
 	// Bind the required arguments
 	_ = telegramCmd.MarkFlagRequired("channelID")
 	_ = telegramCmd.MarkFlagRequired("appID")
 	_ = telegramCmd.MarkFlagRequired("appHash")
+
+	addDownloadCommands(telegramCmd, telegramCmd)
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bookstairs/bookhunter/cmd/flags"
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+// verifyCmd will check the downloaded books by using the integrity manifest.
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the size and checksum of the downloaded books",
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest := file.NewManifest(flags.DownloadPath)
+		records, err := manifest.Records()
+		log.Exit(err)
+
+		if len(records) == 0 {
+			log.Warnf("No downloaded books were recorded in %s", flags.DownloadPath)
+			return
+		}
+
+		printer := log.NewPrinter().
+			Title("Broken Books").
+			Head("Book ID", "Source", "File Name", "Reason")
+		failed := 0
+		for i := range records {
+			record := &records[i]
+			if err := manifest.Verify(record); err != nil {
+				printer.Row(record.BookID, record.Source, record.FileName, err.Error())
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			printer.Print()
+			log.Exit(fmt.Errorf("%d of %d books failed the verification", failed, len(records)))
+		}
+
+		log.Infof("All the %d books have been verified.", len(records))
+	},
+}
+
+func init() {
+	f := verifyCmd.Flags()
+
+	f.StringVarP(&flags.DownloadPath, "download", "d", flags.DownloadPath, "The book directory you want to verify")
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFileNotFound        = errors.New("the downloading file does not exist")
+	ErrRangeNotSatisfiable = errors.New("the server couldn't resume the download from the given offset")
+)
+
+// Content is the downloading file body which may be resumed from a given offset.
+type Content struct {
+	Body   io.ReadCloser // Body is the file content starting from the Offset.
+	Offset int64         // Offset is the real start position of the Body, it's 0 if the server doesn't support Range.
+	Size   int64         // Size is the total size of the file, it's -1 if it's unknown.
+}
+
+// Download the given link by using the HTTP Range request if the offset is greater than 0.
+// The caller should check the Content.Offset for confirming whether the download has been resumed.
+func (c *Client) Download(ctx context.Context, link string, offset int64) (*Content, error) {
+	req := c.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true)
+	if offset > 0 {
+		req.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := req.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	body := resp.RawBody()
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return &Content{Body: body, Offset: 0, Size: resp.RawResponse.ContentLength}, nil
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header().Get("Content-Range"))
+		if !ok {
+			start = offset
+			size = -1
+			if length := resp.RawResponse.ContentLength; length >= 0 {
+				size = offset + length
+			}
+		}
+		return &Content{Body: body, Offset: start, Size: size}, nil
+	}
+
+	// No need to read the body for the failed requests.
+	_ = body.Close()
+
+	switch resp.StatusCode() {
+	case http.StatusNotFound:
+		return nil, ErrFileNotFound
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil, ErrRangeNotSatisfiable
+	default:
+		return nil, fmt.Errorf("unexpected download response status: %s", resp.Status())
+	}
+}
+
+// parseContentRange will parse the header like "bytes 200-1000/67589" into the start position and the total size.
+func parseContentRange(header string) (start, size int64, ok bool) {
+	rs, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	interval, total, found := strings.Cut(rs, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(interval, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	size = -1
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return start, size, true
+}
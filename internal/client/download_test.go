@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Download(t *testing.T) {
+	content := strings.Repeat("bookhunter", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/range" {
+			http.ServeContent(w, r, "book.epub", time.Now(), strings.NewReader(content))
+		} else if r.URL.Path == "/plain" {
+			_, _ = w.Write([]byte(content))
+		} else {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(&Config{})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		link   string
+		offset int64
+		start  int64
+		err    error
+	}{
+		{name: "Resume the download by range", link: "/range", offset: 100, start: 100},
+		{name: "Download from the beginning", link: "/range", offset: 0, start: 0},
+		{name: "Server without range support", link: "/plain", offset: 100, start: 0},
+		{name: "Offset out of range", link: "/range", offset: 5000, err: ErrRangeNotSatisfiable},
+		{name: "File not found", link: "/missing", offset: 0, err: ErrFileNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Download(context.Background(), server.URL+tt.link, tt.offset)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			defer func() { _ = got.Body.Close() }()
+
+			body, err := io.ReadAll(got.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.start, got.Offset)
+			assert.Equal(t, int64(len(content)), got.Size)
+			assert.True(t, bytes.Equal([]byte(content[tt.start:]), body))
+		})
+	}
+}
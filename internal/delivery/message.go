@@ -0,0 +1,96 @@
+package delivery
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+// lineLength is the max length of the base64 encoded lines in the mail.
+const lineLength = 76
+
+// contentTypes are the MIME types of the e-book formats which aren't known by the mime package.
+var contentTypes = map[file.Format]string{
+	file.EPUB: "application/epub+zip",
+	file.AZW3: "application/vnd.amazon.ebook",
+	file.AZW:  "application/vnd.amazon.ebook",
+	file.MOBI: "application/x-mobipocket-ebook",
+	file.PDF:  "application/pdf",
+	file.TXT:  "text/plain",
+}
+
+// newMessage creates the MIME mail which has the book as the attachment.
+func newMessage(from string, to []string, item *Item) ([]byte, error) {
+	content, err := os.ReadFile(item.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	title := item.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(item.Path), filepath.Ext(item.Path))
+	}
+
+	header := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", title),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + w.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	text, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(text, []byte(title))
+
+	contentType, ok := contentTypes[item.Format]
+	if !ok {
+		if contentType = mime.TypeByExtension(filepath.Ext(item.Path)); contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	name := filepath.Base(item.Path)
+	attachment, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": name})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(attachment, content)
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create the mail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 encodes the content in the lines which are no longer than the lineLength.
+func writeBase64(w io.Writer, content []byte) {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > lineLength {
+		_, _ = w.Write([]byte(encoded[:lineLength] + "\r\n"))
+		encoded = encoded[lineLength:]
+	}
+	_, _ = w.Write([]byte(encoded + "\r\n"))
+}
@@ -0,0 +1,208 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+// maxAttempts is the max times for sending a book, the book is removed from the queue after that.
+const maxAttempts = 5
+
+// Item is a book which should be delivered.
+type Item struct {
+	ID        int64       `json:"id"`
+	Source    string      `json:"source"`
+	Title     string      `json:"title"`
+	Path      string      `json:"path"`
+	Format    file.Format `json:"format"`
+	From      string      `json:"from,omitempty"` // The sender address provided by the website, it's the last choice of the sender.
+	Attempts  int         `json:"attempts"`
+	LastError string      `json:"last_error,omitempty"`
+	Queued    time.Time   `json:"queued"`
+}
+
+// key identifies the queued book, the same book may be queued by the concurrent jobs or the re-runs.
+// The queue file is only shared by the jobs with the same SMTP server and recipients, see Config.QueueFile,
+// so the path of the book is enough for identifying the delivery.
+func (i *Item) key() string {
+	return i.Path
+}
+
+// Sender delivers the books by SMTP, the failed books are saved in the queue file for retrying.
+// The queue file is locked when it's read or written, it could be shared by the concurrent jobs.
+type Sender struct {
+	config *Config
+	path   string
+	flock  *flock.Flock
+	lock   sync.Mutex
+}
+
+// NewSender will use the queue file in the given path, the file is created when a book failed.
+func NewSender(config *Config, path string) *Sender {
+	return &Sender{config: config, path: path, flock: flock.New(path + ".lock")}
+}
+
+// Queued returns the number of the books waiting for retrying.
+func (s *Sender) Queued() (int, error) {
+	var items []*Item
+	err := s.locked(func() (err error) {
+		items, err = s.load()
+		return
+	})
+	return len(items), err
+}
+
+// Deliver will send the book. The failed book is saved in the queue and the error is returned.
+func (s *Sender) Deliver(ctx context.Context, item *Item) error {
+	item.Attempts++
+	err := s.config.send(ctx, item)
+	if err == nil {
+		return nil
+	}
+
+	item.LastError = err.Error()
+	item.Queued = time.Now()
+	if e := s.locked(func() error {
+		items, err := s.load()
+		if err != nil {
+			return err
+		}
+		// The book which has been queued is replaced, the attempts are accumulated.
+		for i, queued := range items {
+			if queued.key() == item.key() {
+				item.Attempts += queued.Attempts
+				items[i] = item
+				return s.save(items)
+			}
+		}
+		return s.save(append(items, item))
+	}); e != nil {
+		log.Warnf("Failed to save the delivery queue: %v", e)
+	}
+	return err
+}
+
+// Retry will send the queued books again, it returns the number of the delivered books.
+// The books which have been removed or failed too many times are dropped from the queue.
+func (s *Sender) Retry(ctx context.Context) (int, error) {
+	var items []*Item
+	if err := s.locked(func() (err error) {
+		items, err = s.load()
+		return
+	}); err != nil {
+		return 0, err
+	}
+
+	// The queue isn't locked when sending the books, the finished books are removed from the latest queue.
+	delivered := 0
+	finished := map[string]bool{}
+	failed := map[string]*Item{}
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := os.Stat(item.Path); err != nil {
+			log.Warnf("Drop the book %d from the delivery queue, the file %s is unavailable: %v", item.ID, item.Path, err)
+			finished[item.key()] = true
+			continue
+		}
+
+		item.Attempts++
+		if err := s.config.send(ctx, item); err != nil {
+			item.LastError = err.Error()
+			if item.Attempts >= maxAttempts {
+				log.Warnf("Drop the book %d from the delivery queue after %d attempts: %v", item.ID, item.Attempts, err)
+				finished[item.key()] = true
+				continue
+			}
+			log.Warnf("Failed to deliver the book %d again: %v", item.ID, err)
+			failed[item.key()] = item
+			continue
+		}
+		log.Infof("Delivered the queued book %d: %s", item.ID, item.Path)
+		finished[item.key()] = true
+		delivered++
+	}
+
+	return delivered, s.locked(func() error {
+		items, err := s.load()
+		if err != nil {
+			return err
+		}
+		var remains []*Item
+		for _, item := range items {
+			if finished[item.key()] {
+				continue
+			}
+			if f, ok := failed[item.key()]; ok {
+				item = f
+			}
+			remains = append(remains, item)
+		}
+		return s.save(remains)
+	})
+}
+
+// locked runs the function with the queue file locked.
+func (s *Sender) locked(fn func() error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	if err := s.flock.Lock(); err != nil {
+		return err
+	}
+	defer func() { _ = s.flock.Unlock() }()
+
+	return fn()
+}
+
+// load will read the queue file, the broken file is treated as empty.
+func (s *Sender) load() ([]*Item, error) {
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var items []*Item
+	if err := json.Unmarshal(content, &items); err != nil {
+		log.Warnf("Skip the broken delivery queue %s: %v", s.path, err)
+		return nil, nil
+	}
+	return items, nil
+}
+
+// save will write the queue into the file atomically, the file is removed if the queue is empty.
+func (s *Sender) save(items []*Item) error {
+	if len(items) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	content, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := s.path + ".tmp"
+	if err := os.WriteFile(temp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temp, s.path)
+}
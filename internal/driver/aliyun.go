@@ -1,7 +1,7 @@
 package driver
 
 import (
-	"io"
+	"context"
 	"strings"
 
 	"github.com/bookstairs/bookhunter/internal/client"
@@ -27,16 +27,16 @@ func (a *aliyunDriver) Source() Source {
 	return ALIYUN
 }
 
-func (a *aliyunDriver) Resolve(link, passcode string) ([]Share, error) {
+func (a *aliyunDriver) Resolve(ctx context.Context, link, passcode string) ([]Share, error) {
 	shareID := strings.TrimPrefix(link, "https://www.aliyundrive.com/s/")
 	sharePwd := strings.TrimSpace(passcode)
 
-	token, err := a.client.ShareToken(shareID, sharePwd)
+	token, err := a.client.ShareToken(ctx, shareID, sharePwd)
 	if err != nil {
 		return nil, err
 	}
 
-	files, err := a.client.Share(shareID, token.ShareToken)
+	files, err := a.client.Share(ctx, shareID, token.ShareToken)
 	if err != nil {
 		return nil, err
 	}
@@ -61,17 +61,15 @@ func (a *aliyunDriver) Resolve(link, passcode string) ([]Share, error) {
 	return shares, nil
 }
 
-func (a *aliyunDriver) Download(share Share) (io.ReadCloser, int64, error) {
+func (a *aliyunDriver) Download(ctx context.Context, share Share, offset int64) (*client.Content, error) {
 	shareToken := share.Properties["shareToken"].(string)
 	shareID := share.Properties["shareID"].(string)
 	fileID := share.Properties["fileID"].(string)
 
-	url, err := a.client.DownloadURL(shareToken, shareID, fileID)
+	url, err := a.client.DownloadURL(ctx, shareToken, shareID, fileID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	file, err := a.client.DownloadFile(url)
-
-	return file, 0, err
+	return a.client.DownloadFile(ctx, url, offset)
 }
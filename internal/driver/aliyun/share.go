@@ -1,14 +1,16 @@
 package aliyun
 
 import (
-	"io"
+	"context"
 
+	"github.com/bookstairs/bookhunter/internal/client"
 	"github.com/bookstairs/bookhunter/internal/log"
 )
 
 // AnonymousShare will try to access the share without the user information.
-func (ali *Aliyun) AnonymousShare(shareID string) (*ShareInfoResp, error) {
+func (ali *Aliyun) AnonymousShare(ctx context.Context, shareID string) (*ShareInfoResp, error) {
 	resp, err := ali.R().
+		SetContext(ctx).
 		SetBody(&ShareInfoReq{ShareID: shareID}).
 		SetResult(&ShareInfoResp{}).
 		SetError(&ErrorResp{}).
@@ -20,8 +22,8 @@ func (ali *Aliyun) AnonymousShare(shareID string) (*ShareInfoResp, error) {
 	return resp.Result().(*ShareInfoResp), nil
 }
 
-func (ali *Aliyun) Share(shareID, shareToken string) ([]ShareFile, error) {
-	return ali.listShareFiles(&listShareFilesParam{
+func (ali *Aliyun) Share(ctx context.Context, shareID, shareToken string) ([]ShareFile, error) {
+	return ali.listShareFiles(ctx, &listShareFilesParam{
 		shareToken:   shareToken,
 		shareID:      shareID,
 		parentFileID: "root",
@@ -29,8 +31,9 @@ func (ali *Aliyun) Share(shareID, shareToken string) ([]ShareFile, error) {
 	})
 }
 
-func (ali *Aliyun) listShareFiles(param *listShareFilesParam) ([]ShareFile, error) {
+func (ali *Aliyun) listShareFiles(ctx context.Context, param *listShareFilesParam) ([]ShareFile, error) {
 	resp, err := ali.R().
+		SetContext(ctx).
 		SetHeader("x-share-token", param.shareToken).
 		SetBody(&ShareFileListReq{
 			ShareID:        param.shareID,
@@ -53,7 +56,7 @@ func (ali *Aliyun) listShareFiles(param *listShareFilesParam) ([]ShareFile, erro
 
 	for _, item := range res.Items {
 		if item.FileType == "folder" {
-			list, err := ali.listShareFiles(&listShareFilesParam{
+			list, err := ali.listShareFiles(ctx, &listShareFilesParam{
 				shareToken:   param.shareToken,
 				shareID:      param.shareID,
 				parentFileID: item.FileID,
@@ -70,7 +73,7 @@ func (ali *Aliyun) listShareFiles(param *listShareFilesParam) ([]ShareFile, erro
 	}
 
 	if res.NextMarker != "" {
-		list, err := ali.listShareFiles(&listShareFilesParam{
+		list, err := ali.listShareFiles(ctx, &listShareFilesParam{
 			shareToken:   param.shareToken,
 			shareID:      param.shareID,
 			parentFileID: param.parentFileID,
@@ -86,8 +89,9 @@ func (ali *Aliyun) listShareFiles(param *listShareFilesParam) ([]ShareFile, erro
 	return files, nil
 }
 
-func (ali *Aliyun) ShareToken(shareID, sharePwd string) (*ShareTokenResp, error) {
+func (ali *Aliyun) ShareToken(ctx context.Context, shareID, sharePwd string) (*ShareTokenResp, error) {
 	resp, err := ali.R().
+		SetContext(ctx).
 		SetBody(&ShareTokenReq{ShareID: shareID, SharePwd: sharePwd}).
 		SetResult(&ShareTokenResp{}).
 		SetError(&ErrorResp{}).
@@ -99,8 +103,9 @@ func (ali *Aliyun) ShareToken(shareID, sharePwd string) (*ShareTokenResp, error)
 	return resp.Result().(*ShareTokenResp), nil
 }
 
-func (ali *Aliyun) DownloadURL(shareToken, shareID, fileID string) (string, error) {
+func (ali *Aliyun) DownloadURL(ctx context.Context, shareToken, shareID, fileID string) (string, error) {
 	resp, err := ali.R().
+		SetContext(ctx).
 		SetHeader("x-share-token", shareToken).
 		SetBody(&ShareLinkDownloadURLReq{
 			ShareID: shareID,
@@ -119,15 +124,8 @@ func (ali *Aliyun) DownloadURL(shareToken, shareID, fileID string) (string, erro
 	return res.DownloadURL, nil
 }
 
-func (ali *Aliyun) DownloadFile(downloadURL string) (io.ReadCloser, error) {
+func (ali *Aliyun) DownloadFile(ctx context.Context, downloadURL string, offset int64) (*client.Content, error) {
 	log.Debugf("Start to download file from aliyun drive: %s", downloadURL)
 
-	resp, err := ali.R().
-		SetDoNotParseResponse(true).
-		Get(downloadURL)
-	if err != nil {
-		return nil, err
-	}
-
-	return resp.RawBody(), err
+	return ali.Download(ctx, downloadURL, offset)
 }
@@ -1,10 +1,11 @@
 package driver
 
 import (
+	"context"
 	"fmt"
-	"io"
 
 	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/file"
 )
 
 type (
@@ -21,6 +22,8 @@ type (
 		Size int64
 		// URL is the downloadable url for this file.
 		URL string
+		// Metadata is the book information, it's nil if the service doesn't expose it.
+		Metadata *file.Metadata
 		// Properties could be some metadata, such as the token for this downloadable share.
 		Properties map[string]any
 	}
@@ -31,10 +34,10 @@ type (
 		Source() Source
 
 		// Resolve the given link and return the file name with the download link.
-		Resolve(link, passcode string) ([]Share, error)
+		Resolve(ctx context.Context, link, passcode string) ([]Share, error)
 
-		// Download the given link.
-		Download(share Share) (io.ReadCloser, int64, error)
+		// Download the given share from the offset. The download will be resumed if the server supports it.
+		Download(ctx context.Context, share Share, offset int64) (*client.Content, error)
 	}
 )
 
@@ -1,7 +1,7 @@
 package driver
 
 import (
-	"io"
+	"context"
 
 	"github.com/bookstairs/bookhunter/internal/client"
 	"github.com/bookstairs/bookhunter/internal/driver/lanzou"
@@ -24,8 +24,8 @@ func (l *lanzouDriver) Source() Source {
 	return LANZOU
 }
 
-func (l *lanzouDriver) Resolve(link, passcode string) ([]Share, error) {
-	resp, err := l.driver.ResolveShareURL(link, passcode)
+func (l *lanzouDriver) Resolve(ctx context.Context, link, passcode string) ([]Share, error) {
+	resp, err := l.driver.ResolveShareURL(ctx, link, passcode)
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +39,6 @@ func (l *lanzouDriver) Resolve(link, passcode string) ([]Share, error) {
 	return shareList, err
 }
 
-func (l *lanzouDriver) Download(share Share) (io.ReadCloser, int64, error) {
-	return l.driver.DownloadFile(share.URL)
+func (l *lanzouDriver) Download(ctx context.Context, share Share, offset int64) (*client.Content, error) {
+	return l.driver.DownloadFile(ctx, share.URL, offset)
 }
@@ -1,8 +1,8 @@
 package lanzou
 
 import (
+	"context"
 	"fmt"
-	"io"
 
 	"github.com/bookstairs/bookhunter/internal/client"
 	"github.com/bookstairs/bookhunter/internal/log"
@@ -61,15 +61,8 @@ func New(config *client.Config) (*Lanzou, error) {
 	return &Lanzou{Client: cl}, nil
 }
 
-func (l *Lanzou) DownloadFile(downloadURL string) (io.ReadCloser, int64, error) {
-	log.Debugf("Start to download file from aliyun drive: %s", downloadURL)
+func (l *Lanzou) DownloadFile(ctx context.Context, downloadURL string, offset int64) (*client.Content, error) {
+	log.Debugf("Start to download file from lanzou drive: %s", downloadURL)
 
-	resp, err := l.R().
-		SetDoNotParseResponse(true).
-		Get(downloadURL)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return resp.RawBody(), resp.RawResponse.ContentLength, nil
+	return l.Download(ctx, downloadURL, offset)
 }
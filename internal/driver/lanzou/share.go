@@ -1,6 +1,7 @@
 package lanzou
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -14,23 +15,23 @@ import (
 	"github.com/bookstairs/bookhunter/internal/log"
 )
 
-func (l *Lanzou) ResolveShareURL(shareURL, pwd string) ([]ResponseData, error) {
+func (l *Lanzou) ResolveShareURL(ctx context.Context, shareURL, pwd string) ([]ResponseData, error) {
 	shareURL = strings.TrimSpace(shareURL)
 	// 移除url前部的主机
 	rawURL, _ := url.Parse(shareURL)
 	parsedURI := rawURL.RequestURI()
 
 	if l.IsFileURL(shareURL) {
-		fileShareURL, err := l.resolveFileShareURL(parsedURI, pwd)
+		fileShareURL, err := l.resolveFileShareURL(ctx, parsedURI, pwd)
 		if err != nil {
 			return nil, err
 		}
 		return []ResponseData{*fileShareURL}, err
 	} else if l.IsDirURL(shareURL) {
-		return l.resolveFileItemShareURL(parsedURI, pwd)
+		return l.resolveFileItemShareURL(ctx, parsedURI, pwd)
 	} else {
 		log.Warnf("Unexpected share url, try to download by using directory share API. %s", shareURL)
-		return l.resolveFileItemShareURL(parsedURI, pwd)
+		return l.resolveFileItemShareURL(ctx, parsedURI, pwd)
 	}
 }
 
@@ -48,8 +49,8 @@ func (l *Lanzou) removeNotes(html string) string {
 	return html
 }
 
-func (l *Lanzou) resolveFileShareURL(parsedURI, pwd string) (*ResponseData, error) {
-	resp, err := l.R().Get(parsedURI)
+func (l *Lanzou) resolveFileShareURL(ctx context.Context, parsedURI, pwd string) (*ResponseData, error) {
+	resp, err := l.R().SetContext(ctx).Get(parsedURI)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +67,7 @@ func (l *Lanzou) resolveFileShareURL(parsedURI, pwd string) (*ResponseData, erro
 			Value: acwScV2,
 		})
 		log.Infof("Set Cookie: acw_sc__v2=%v", acwScV2)
-		get, _ := l.R().Get(parsedURI)
+		get, _ := l.R().SetContext(ctx).Get(parsedURI)
 		firstPage = get.String()
 	}
 
@@ -80,15 +81,15 @@ func (l *Lanzou) resolveFileShareURL(parsedURI, pwd string) (*ResponseData, erro
 		if pwd == "" {
 			return nil, fmt.Errorf("缺少密码 %v", parsedURI)
 		}
-		return l.ParsePasswordShare(parsedURI, pwd, firstPage)
+		return l.ParsePasswordShare(ctx, parsedURI, pwd, firstPage)
 	} else if find2Re.MatchString(firstPage) {
-		lanzouDom, err := l.ParseAnonymousShare(parsedURI, firstPage)
+		lanzouDom, err := l.ParseAnonymousShare(ctx, parsedURI, firstPage)
 		return lanzouDom, err
 	}
 	return nil, fmt.Errorf("解析页面失败")
 }
 
-func (l *Lanzou) ParsePasswordShare(parsedURI string, pwd string, firstPage string) (*ResponseData, error) {
+func (l *Lanzou) ParsePasswordShare(ctx context.Context, parsedURI string, pwd string, firstPage string) (*ResponseData, error) {
 	sign := find1Re.FindStringSubmatch(firstPage)
 	urlpath := "/ajaxm.php"
 
@@ -99,6 +100,7 @@ func (l *Lanzou) ParsePasswordShare(parsedURI string, pwd string, firstPage stri
 	data["p"] = pwd
 
 	_, err := l.R().
+		SetContext(ctx).
 		SetHeader("referer", l.BaseURL+parsedURI).
 		SetHeader("Content-Type", "application/x-www-form-urlencoded").
 		SetResult(result).
@@ -107,13 +109,13 @@ func (l *Lanzou) ParsePasswordShare(parsedURI string, pwd string, firstPage stri
 	if err != nil {
 		return nil, err
 	}
-	return l.parseDom(result)
+	return l.parseDom(ctx, result)
 }
 
-func (l *Lanzou) ParseAnonymousShare(parsedURI string, firstPage string) (*ResponseData, error) {
+func (l *Lanzou) ParseAnonymousShare(ctx context.Context, parsedURI string, firstPage string) (*ResponseData, error) {
 	allString := find2Re.FindStringSubmatch(firstPage)
 
-	dom, err := l.R().Get(allString[1])
+	dom, err := l.R().SetContext(ctx).Get(allString[1])
 	if err != nil {
 		return nil, err
 	}
@@ -142,6 +144,7 @@ func (l *Lanzou) ParseAnonymousShare(parsedURI string, firstPage string) (*Respo
 
 	result := &Dom{}
 	_, err = l.R().
+		SetContext(ctx).
 		SetHeader("origin", l.BaseURL).
 		SetHeader("referer", l.BaseURL+parsedURI).
 		SetHeader("Content-Type", "application/x-www-form-urlencoded").
@@ -151,14 +154,14 @@ func (l *Lanzou) ParseAnonymousShare(parsedURI string, firstPage string) (*Respo
 	if err != nil {
 		return nil, err
 	}
-	lanzouDom, err := l.parseDom(result)
+	lanzouDom, err := l.parseDom(ctx, result)
 	if lanzouDom != nil {
 		lanzouDom.Name = title
 	}
 	return lanzouDom, err
 }
 
-func (l *Lanzou) parseDom(result *Dom) (*ResponseData, error) {
+func (l *Lanzou) parseDom(ctx context.Context, result *Dom) (*ResponseData, error) {
 	if result.Zt != 1 {
 		return nil, fmt.Errorf("解析直链失败")
 	}
@@ -169,7 +172,8 @@ func (l *Lanzou) parseDom(result *Dom) (*ResponseData, error) {
 	}
 
 	request := resty.New().SetRedirectPolicy(resty.NoRedirectPolicy()).
-		R()
+		R().
+		SetContext(ctx)
 	rr, err := request.SetHeaders(header).
 		Get(result.Dom + "/file/" + result.URL.(string))
 	if rr.StatusCode() != 302 && err != nil {
@@ -231,8 +235,8 @@ func (l *Lanzou) extractRegex(reg *regexp.Regexp, str string) string {
 	return ""
 }
 
-func (l *Lanzou) resolveFileItemShareURL(parsedURI, pwd string) ([]ResponseData, error) {
-	resp, _ := l.R().Get(parsedURI)
+func (l *Lanzou) resolveFileItemShareURL(ctx context.Context, parsedURI, pwd string) ([]ResponseData, error) {
+	resp, _ := l.R().SetContext(ctx).Get(parsedURI)
 	str := resp.String()
 	formData := map[string]string{
 		"lx":  l.extractRegex(lxReg, str),
@@ -248,7 +252,7 @@ func (l *Lanzou) resolveFileItemShareURL(parsedURI, pwd string) ([]ResponseData,
 	}
 
 	result := &FileList{}
-	_, err := l.R().SetFormData(formData).SetResult(result).Post("/filemoreajax.php")
+	_, err := l.R().SetContext(ctx).SetFormData(formData).SetResult(result).Post("/filemoreajax.php")
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +273,7 @@ func (l *Lanzou) resolveFileItemShareURL(parsedURI, pwd string) ([]ResponseData,
 	for i, d := range item {
 		file := d.(map[string]interface{})
 
-		respData, err := l.resolveFileShareURL("/"+file["id"].(string), pwd)
+		respData, err := l.resolveFileShareURL(ctx, "/"+file["id"].(string), pwd)
 		if err != nil {
 			return nil, err
 		}
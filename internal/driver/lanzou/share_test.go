@@ -1,6 +1,7 @@
 package lanzou
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -88,7 +89,7 @@ func TestParseLanzouUrl(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			response, err := drive.ResolveShareURL(tt.args.url, tt.args.pwd)
+			response, err := drive.ResolveShareURL(context.Background(), tt.args.url, tt.args.pwd)
 			assert.NoError(t, err, "Failed to resolve link")
 			assert.NotEmpty(t, response)
 
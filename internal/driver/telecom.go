@@ -1,7 +1,7 @@
 package driver
 
 import (
-	"io"
+	"context"
 	"strconv"
 
 	"github.com/bookstairs/bookhunter/internal/client"
@@ -26,13 +26,13 @@ func (t *telecomDriver) Source() Source {
 	return TELECOM
 }
 
-func (t *telecomDriver) Resolve(link, passcode string) ([]Share, error) {
+func (t *telecomDriver) Resolve(ctx context.Context, link, passcode string) ([]Share, error) {
 	code, err := t.client.ShareCode(link)
 	if err != nil {
 		return nil, err
 	}
 
-	info, files, err := t.client.ShareFiles(link, passcode)
+	info, files, err := t.client.ShareFiles(ctx, link, passcode)
 	if err != nil {
 		return nil, err
 	}
@@ -54,19 +54,17 @@ func (t *telecomDriver) Resolve(link, passcode string) ([]Share, error) {
 	return shares, nil
 }
 
-func (t *telecomDriver) Download(share Share) (io.ReadCloser, int64, error) {
+func (t *telecomDriver) Download(ctx context.Context, share Share, offset int64) (*client.Content, error) {
 	shareCode := share.Properties["shareCode"].(string)
 	shareID := share.Properties["shareID"].(string)
 	fileID := share.Properties["fileID"].(string)
 
 	// Resolve the link.
-	url, err := t.client.DownloadURL(shareCode, shareID, fileID)
+	url, err := t.client.DownloadURL(ctx, shareCode, shareID, fileID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	// Download the file.
-	file, err := t.client.DownloadFile(url)
-
-	return file, 0, err
+	return t.client.DownloadFile(ctx, url, offset)
 }
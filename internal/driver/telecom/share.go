@@ -1,20 +1,21 @@
 package telecom
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"strconv"
 	"strings"
 
+	"github.com/bookstairs/bookhunter/internal/client"
 	"github.com/bookstairs/bookhunter/internal/log"
 )
 
 // ShareFiles will resolve the telecom-shared link.
-func (t *Telecom) ShareFiles(accessURL, accessCode string) (*ShareInfo, []ShareFile, error) {
+func (t *Telecom) ShareFiles(ctx context.Context, accessURL, accessCode string) (*ShareInfo, []ShareFile, error) {
 	log.Debugf("Download telecom file from %s -- %s", accessURL, accessCode)
 
 	// Get share info.
-	info, err := t.shareInfo(accessURL)
+	info, err := t.shareInfo(ctx, accessURL)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -22,9 +23,9 @@ func (t *Telecom) ShareFiles(accessURL, accessCode string) (*ShareInfo, []ShareF
 	// Reclusive get the shared files.
 	var files []ShareFile
 	if info.IsFolder {
-		files, err = t.listShareFolders(accessCode, info.FileID, info.FileID, info.ShareID, info.ShareMode)
+		files, err = t.listShareFolders(ctx, accessCode, info.FileID, info.FileID, info.ShareID, info.ShareMode)
 	} else {
-		files, err = t.listShareFiles(accessCode, info.FileID, info.ShareID, info.ShareMode)
+		files, err = t.listShareFiles(ctx, accessCode, info.FileID, info.ShareID, info.ShareMode)
 	}
 	if err != nil {
 		return nil, nil, err
@@ -33,8 +34,9 @@ func (t *Telecom) ShareFiles(accessURL, accessCode string) (*ShareInfo, []ShareF
 	return info, files, nil
 }
 
-func (t *Telecom) DownloadURL(shareCode, shareID, fileID string) (string, error) {
+func (t *Telecom) DownloadURL(ctx context.Context, shareCode, shareID, fileID string) (string, error) {
 	resp, err := t.R().
+		SetContext(ctx).
 		SetHeaders(map[string]string{
 			"accept":  "application/json;charset=UTF-8",
 			"origin":  "https://cloud.189.cn",
@@ -55,15 +57,8 @@ func (t *Telecom) DownloadURL(shareCode, shareID, fileID string) (string, error)
 	return link.FileDownloadURL, nil
 }
 
-func (t *Telecom) DownloadFile(url string) (io.ReadCloser, error) {
-	resp, err := t.R().
-		SetDoNotParseResponse(true).
-		Get(url)
-	if err != nil {
-		return nil, err
-	}
-
-	return resp.RawBody(), nil
+func (t *Telecom) DownloadFile(ctx context.Context, url string, offset int64) (*client.Content, error) {
+	return t.Download(ctx, url, offset)
 }
 
 // ShareCode extract the share code.
@@ -76,12 +71,13 @@ func (t *Telecom) ShareCode(accessURL string) (string, error) {
 	}
 }
 
-func (t *Telecom) shareInfo(accessURL string) (*ShareInfo, error) {
+func (t *Telecom) shareInfo(ctx context.Context, accessURL string) (*ShareInfo, error) {
 	shareCode, err := t.ShareCode(accessURL)
 	if err != nil {
 		return nil, err
 	}
 	resp, err := t.R().
+		SetContext(ctx).
 		SetHeaders(map[string]string{
 			"accept":  "application/json;charset=UTF-8",
 			"origin":  "https://cloud.189.cn",
@@ -97,8 +93,9 @@ func (t *Telecom) shareInfo(accessURL string) (*ShareInfo, error) {
 	return resp.Result().(*ShareInfo), nil
 }
 
-func (t *Telecom) listShareFiles(code, fileID string, shareID int64, mode int) ([]ShareFile, error) {
+func (t *Telecom) listShareFiles(ctx context.Context, code, fileID string, shareID int64, mode int) ([]ShareFile, error) {
 	resp, err := t.R().
+		SetContext(ctx).
 		SetQueryParams(map[string]string{
 			"fileId":     fileID,
 			"shareId":    strconv.FormatInt(shareID, 10),
@@ -119,8 +116,9 @@ func (t *Telecom) listShareFiles(code, fileID string, shareID int64, mode int) (
 	return res.FileListAO.FileList, nil
 }
 
-func (t *Telecom) listShareFolders(code, fileID, shareDirFileID string, shareID int64, mode int) ([]ShareFile, error) {
+func (t *Telecom) listShareFolders(ctx context.Context, code, fileID, shareDirFileID string, shareID int64, mode int) ([]ShareFile, error) {
 	resp, err := t.R().
+		SetContext(ctx).
 		SetQueryParams(map[string]string{
 			"fileId":         fileID,
 			"shareDirFileId": shareDirFileID,
@@ -145,7 +143,7 @@ func (t *Telecom) listShareFolders(code, fileID, shareDirFileID string, shareID
 
 	for _, folder := range res.FolderList {
 		id := strconv.FormatInt(folder.ID, 10)
-		children, err := t.listShareFolders(code, id, shareDirFileID, shareID, mode)
+		children, err := t.listShareFolders(ctx, code, id, shareDirFileID, shareID, mode)
 		if err != nil {
 			return nil, err
 		}
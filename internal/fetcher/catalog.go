@@ -0,0 +1,182 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/log"
+	"github.com/bookstairs/bookhunter/internal/progress"
+)
+
+type ListFormat string // The output format of the dry-run catalog.
+
+const (
+	TableList ListFormat = "table"
+	CSVList   ListFormat = "csv"
+	JSONList  ListFormat = "json"
+)
+
+// ParseListFormat will create the list format from the string.
+func ParseListFormat(format string) (ListFormat, error) {
+	switch f := ListFormat(strings.ToLower(format)); f {
+	case TableList, CSVList, JSONList:
+		return f, nil
+	case "":
+		return TableList, nil
+	default:
+		return "", fmt.Errorf("invalid list format %s, it should be table, csv or json", format)
+	}
+}
+
+// Entry is a downloadable file found in the dry-run mode.
+type Entry struct {
+	BookID int64       `json:"id"`
+	Title  string      `json:"title"`
+	Format file.Format `json:"format"`
+	Size   int64       `json:"size"`
+	URL    string      `json:"url,omitempty"`
+}
+
+// list will walk through the books and print the files which would be downloaded.
+// No files will be created and the download progress won't be touched.
+func (f *fetcher) list(ctx context.Context, size int64) error {
+	ids := f.BookIDs
+	if len(ids) == 0 {
+		end := size
+		if f.EndBookID > 0 && f.EndBookID < end {
+			end = f.EndBookID
+		}
+		for id := f.InitialBookID; id <= end; id++ {
+			ids = append(ids, id)
+		}
+	}
+
+	books, err := progress.NewListProgress(ids, size, f.RateLimit*f.Thread)
+	if err != nil {
+		return err
+	}
+
+	var (
+		entries []Entry
+		lock    sync.Mutex
+		wait    sync.WaitGroup
+	)
+	errs := make(chan error, f.Thread)
+	for i := 0; i < f.Thread; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for ctx.Err() == nil {
+				bookID := books.AcquireBookID()
+				if bookID == progress.NoBookToDownload {
+					return
+				}
+
+				formats, err := f.service.formats(ctx, bookID)
+				if err != nil {
+					if ctx.Err() == nil {
+						errs <- err
+					}
+					return
+				}
+				// The preferred format is chosen from the files which match the filters.
+				formats = f.filterFormats(formats)
+				if !f.Filter.Empty() {
+					formats = f.filterShares(formats)
+				}
+				formats = f.preferFormats(formats)
+
+				lock.Lock()
+				for format, share := range formats {
+					entries = append(entries, Entry{
+						BookID: bookID,
+						Title:  strings.TrimSuffix(share.FileName, "."+string(format)),
+						Format: format,
+						Size:   share.Size,
+						URL:    share.URL,
+					})
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	wait.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].BookID != entries[j].BookID {
+			return entries[i].BookID < entries[j].BookID
+		}
+		return entries[i].Format < entries[j].Format
+	})
+
+	out := io.Writer(os.Stdout)
+	if f.ListOutput != "" {
+		file, err := os.Create(f.ListOutput)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+	if err := WriteEntries(out, f.ListFormat, entries); err != nil {
+		return err
+	}
+	log.Infof("Found %d downloadable files in %d books.", len(entries), len(ids))
+
+	if ctx.Err() != nil {
+		return ErrDownloadInterrupted
+	}
+	return nil
+}
+
+// WriteEntries will write the catalog entries in the given format.
+func WriteEntries(w io.Writer, format ListFormat, entries []Entry) error {
+	switch format {
+	case CSVList:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"id", "title", "format", "size", "url"}); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			record := []string{strconv.FormatInt(e.BookID, 10), e.Title, string(e.Format), strconv.FormatInt(e.Size, 10), e.URL}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case JSONList:
+		if entries == nil {
+			entries = []Entry{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	default:
+		writer := table.NewWriter()
+		writer.SetOutputMirror(w)
+		writer.SetTitle("Downloadable Books")
+		writer.AppendHeader(table.Row{"Book ID", "Title", "Format", "Size", "URL"})
+		for _, e := range entries {
+			writer.AppendRow(table.Row{e.BookID, e.Title, e.Format, e.Size, e.URL})
+		}
+		writer.Render()
+		return nil
+	}
+}
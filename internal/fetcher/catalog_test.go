@@ -0,0 +1,87 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+type stubService struct {
+	books  map[int64]map[file.Format]driver.Share
+	dryRun bool
+}
+
+func (s *stubService) size(context.Context) (int64, error) {
+	return int64(len(s.books)), nil
+}
+
+func (s *stubService) formats(_ context.Context, id int64) (map[file.Format]driver.Share, error) {
+	return s.books[id], nil
+}
+
+// fetch will write the share URL as the file content, the share without URL is a broken download.
+// The partial file is resumed from the written offset.
+func (s *stubService) fetch(_ context.Context, _ int64, _ file.Format, share driver.Share, writer file.Writer) error {
+	if s.dryRun {
+		panic("the dry-run mode shouldn't download any files")
+	}
+	if share.URL == "" {
+		return errors.New("the download link is expired")
+	}
+	_, err := writer.Write([]byte(share.URL[writer.Offset():]))
+	return err
+}
+
+func TestFetcher_List(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "books.json")
+	f := &fetcher{
+		Config: &Config{
+			Formats:       []file.Format{file.EPUB, file.PDF},
+			Prefer:        []file.Format{file.PDF, file.EPUB},
+			Filter:        Filter{Keywords: []string{"Go"}, MaxSize: 15},
+			InitialBookID: 1,
+			EndBookID:     2,
+			Thread:        2,
+			RateLimit:     1000,
+			ListFormat:    JSONList,
+			ListOutput:    output,
+		},
+		service: &stubService{dryRun: true, books: map[int64]map[file.Format]driver.Share{
+			1: {
+				file.EPUB: {FileName: "The Go Programming Language.epub", Size: 10, URL: "https://example.com/1.epub"},
+				file.MOBI: {FileName: "The Go Programming Language.mobi", Size: 20},
+				// The preferred format is too large, the EPUB file should be chosen.
+				file.PDF: {FileName: "The Go Programming Language.pdf", Size: 50},
+			},
+			2: {file.PDF: {FileName: "Rust in Action.pdf", Size: 30}},
+			3: {file.PDF: {FileName: "Go in Action.pdf", Size: 40}},
+		}},
+	}
+
+	assert.NoError(t, f.list(context.Background(), 3))
+
+	content, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	var entries []Entry
+	assert.NoError(t, json.Unmarshal(content, &entries))
+	assert.Equal(t, []Entry{{
+		BookID: 1,
+		Title:  "The Go Programming Language",
+		Format: file.EPUB,
+		Size:   10,
+		URL:    "https://example.com/1.epub",
+	}}, entries)
+
+	// Only the catalog file should be created.
+	files, _ := os.ReadDir(dir)
+	assert.Len(t, files, 1)
+}
@@ -1,20 +1,29 @@
 package fetcher
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.uber.org/ratelimit"
+	"golang.org/x/text/encoding"
 
 	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/delivery"
 	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/hook"
+	"github.com/bookstairs/bookhunter/internal/progress"
 )
 
 var (
 	ErrOverrideRedirectHandler = errors.New("couldn't override the existed redirect handler")
 	ErrFileNotExist            = errors.New("current file does not exist")
+	ErrUnexpectedRange         = errors.New("the server responds an unexpected range of the file")
 )
 
 type Category string // The fetcher service identity.
@@ -29,18 +38,50 @@ const (
 
 // Config is used to define a common config for a specified fetcher service.
 type Config struct {
-	Category      Category      // The identity of the fetcher service.
-	Formats       []file.Format // The formats that the user wants.
-	Keywords      []string      // The keywords that the user wants.
-	Extract       bool          // Extract the archives after download.
-	DownloadPath  string        // The path for storing the file.
-	InitialBookID int64         // The book id start to download.
-	Rename        bool          // Rename the file by using book ID.
-	Thread        int           // The number of download threads.
-	RateLimit     int           // Request per minute for a thread.
-	Retry         int           // The retry times for a failed download.
-	SkipError     bool          // Continue to download the next book if the current book download failed.
-	processFile   string        // Define the download process.
+	Category      Category          // The identity of the fetcher service.
+	Formats       []file.Format     // The formats that the user wants.
+	Prefer        []file.Format     // Only download the best available format in this order, then the Formats order.
+	AlsoKeep      []file.Format     // The formats which are always downloaded with the preferred format.
+	AnyFormat     bool              // Accept the files with the unknown extensions.
+	Filter        Filter            // The filter for choosing the books that the user wants.
+	Extract       bool              // Extract the archives after download.
+	Dedupe        file.Dedupe       // The policy for the books which have been downloaded from any sources.
+	Conflict      file.Conflict     // The policy for the existing files which have the same name.
+	DownloadPath  string            // The path for storing the file.
+	InitialBookID int64             // The book id start to download.
+	EndBookID     int64             // The last book id to download, zero means no limit.
+	BookIDs       []int64           // Only download the given book ids without using the progress file.
+	Rename        bool              // Rename the file by using book ID.
+	Thread        int               // The number of download threads.
+	RateLimit     int               // Request per minute for a thread.
+	Retry         int               // The retry times for a failed download.
+	SkipError     bool              // Continue to download the next book if the current book download failed.
+	RetryFailed   bool              // Only download the failed books in the previous executions.
+	DryRun        bool              // List the files which would be downloaded without downloading them.
+	ListFormat    ListFormat        // The output format for the dry-run mode.
+	ListOutput    string            // The file for saving the dry-run output, the stdout will be used if it's empty.
+	ReportPath    string            // The file for saving the JSON report of the download.
+	HostLimit     ratelimit.Limiter // The shared ratelimit for the fetchers which download from the same host.
+	Watch         time.Duration     // Keep downloading the new books in the given interval, zero means download once.
+	Storage       progress.Storage  // The storage backend for the download progress.
+	processFile   string            // Define the download process.
+
+	// The template for generating the file path, it's optional.
+	NameTemplate *file.NameTemplate
+
+	// The format of the metadata sidecar, and whether to embed the metadata into the downloaded EPUB files.
+	Metadata      file.MetadataFormat
+	EmbedMetadata bool
+
+	// The post-processing steps for every downloaded file.
+	Hooks hook.Pipeline
+
+	// The SMTP delivery for sending the downloaded books to the e-readers, it's optional.
+	Delivery *delivery.Config
+
+	// The limits and the file name encoding for extracting the archives.
+	ExtractLimits   file.ExtractLimits
+	ArchiveEncoding encoding.Encoding
 
 	// The extra configuration for a custom fetcher services.
 	Properties map[string]string
@@ -48,6 +89,11 @@ type Config struct {
 	*client.Config
 }
 
+// AcceptFormat will check if the format could be downloaded.
+func (c *Config) AcceptFormat(format file.Format) bool {
+	return IsValidFormat(format) || (c.AnyFormat && format != "")
+}
+
 // Property will require an existed property from the config.
 func (c *Config) Property(name string) string {
 	if v, ok := c.Properties[name]; ok {
@@ -102,6 +148,10 @@ func IsValidFormat(format file.Format) bool {
 		return true
 	case file.ZIP:
 		return true
+	case file.TXT, file.DJVU, file.FB2, file.CBZ, file.CBR, file.DOCX:
+		return true
+	case file.RAR, file.SevenZip:
+		return true
 	default:
 		return false
 	}
@@ -116,3 +166,40 @@ func New(c *Config) (Fetcher, error) {
 
 	return &fetcher{Config: c, service: s}, nil
 }
+
+// downloadContent will save the link content into the writer.
+// The download would be resumed from the written offset if the server supports HTTP Range.
+func downloadContent(ctx context.Context, c *client.Client, link string, writer file.Writer) error {
+	content, err := c.Download(ctx, link, writer.Offset())
+	if err != nil {
+		if errors.Is(err, client.ErrFileNotFound) {
+			return ErrFileNotExist
+		}
+		if errors.Is(err, client.ErrRangeNotSatisfiable) {
+			// The partial file is broken, download it from the beginning in the next retry.
+			_ = writer.Reset()
+		}
+		return err
+	}
+	body := content.Body
+	defer func() { _ = body.Close() }()
+
+	if offset := writer.Offset(); content.Offset != offset {
+		// The server responds a range which can't be appended, download it from the beginning in the next retry.
+		if content.Offset != 0 {
+			_ = writer.Reset()
+			return fmt.Errorf("%w: expected %d, got %d", ErrUnexpectedRange, offset, content.Offset)
+		}
+		// The server doesn't support Range, discard the downloaded content.
+		if err := writer.Reset(); err != nil {
+			return err
+		}
+	}
+	if content.Size > 0 {
+		writer.SetSize(content.Size)
+	}
+
+	// Save the download content info files.
+	_, err = io.Copy(writer, body)
+	return err
+}
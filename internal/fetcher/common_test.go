@@ -0,0 +1,40 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+func TestDownloadContent_UnexpectedRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server ignores the requested offset and responds another range.
+		w.Header().Set("Content-Range", "bytes 2-11/12")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte("e new book"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "book.epub.part"), []byte("the "), 0o644))
+	c, err := client.New(&client.Config{})
+	assert.NoError(t, err)
+	w, err := file.NewCreator(&file.CreatorConfig{DownloadPath: dir, Formats: []file.Format{file.EPUB}}).
+		NewWriter(1, 1, "book", "", file.EPUB, 0, nil)
+	assert.NoError(t, err)
+	defer func() { _ = w.Abort() }()
+
+	// The mismatched content isn't written, and the partial file is discarded for the retry.
+	assert.Equal(t, int64(4), w.Offset())
+	err = downloadContent(context.Background(), c, server.URL+"/book.epub", w)
+	assert.ErrorIs(t, err, ErrUnexpectedRange)
+	assert.Equal(t, int64(0), w.Offset())
+}
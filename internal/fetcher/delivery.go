@@ -0,0 +1,44 @@
+package fetcher
+
+import (
+	"context"
+
+	"github.com/bookstairs/bookhunter/internal/delivery"
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+// mailSender is implemented by the service which provides the sender address for delivering the books.
+type mailSender interface {
+	mailSender() string
+}
+
+// deliver will send the preferred file of the downloaded book by SMTP.
+// The failed book is queued and sent again in the next execution.
+func (f *fetcher) deliver(ctx context.Context, bookID int64, formats map[file.Format]driver.Share, outputs []file.Output) {
+	format, output, ok := f.Delivery.Choose(outputs)
+	if !ok {
+		log.Infof("Skip delivering the book %d, no files in %v formats are smaller than the size limit", bookID, f.Delivery.Formats)
+		return
+	}
+
+	item := &delivery.Item{ID: bookID, Source: string(f.Category), Path: output.Path, Format: format}
+	for _, share := range formats {
+		if share.Metadata != nil && share.Metadata.Title != "" {
+			item.Title = share.Metadata.Title
+			break
+		}
+	}
+	if s, ok := f.service.(mailSender); ok {
+		item.From = s.mailSender()
+	}
+
+	if err := f.sender.Deliver(ctx, item); err != nil {
+		log.Warnf("Failed to deliver the book %d, it will be sent again in the next execution: %v", bookID, err)
+		f.report.queue()
+		return
+	}
+	log.Infof("Delivered the book %d: %s", bookID, output.Path)
+	f.report.deliver(1)
+}
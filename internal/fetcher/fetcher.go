@@ -1,29 +1,38 @@
 package fetcher
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/delivery"
 	"github.com/bookstairs/bookhunter/internal/driver"
 	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/hook"
 	"github.com/bookstairs/bookhunter/internal/log"
 	"github.com/bookstairs/bookhunter/internal/progress"
 )
 
 const (
 	defaultProgressFile = "progress.db"
+	contentIndexFile    = "content.index"
+	maxWatchBackoff     = 6 * time.Hour
 )
 
+var ErrDownloadInterrupted = errors.New("the download has been interrupted, the finished progress was saved")
+
 // Fetcher exposes the download method to the command line.
 type Fetcher interface {
-	// Download the books from the given service.
-	Download() error
+	// Download the books from the given service. The download will be stopped once the context is canceled.
+	Download(ctx context.Context) error
+
+	// Report returns the summary of the download.
+	Report() *Report
 }
 
 // fetcher is the basic common download service the multiple thread support.
@@ -32,11 +41,64 @@ type fetcher struct {
 	service  service
 	progress progress.Progress
 	creator  file.Creator
+	manifest *file.Manifest
+	index    *file.Index
+	covers   *client.Client
+	sender   *delivery.Sender
+	report   *Report
 	errs     chan error
 }
 
-// Download the books from the given service.
-func (f *fetcher) Download() error {
+// Report returns the summary of the download.
+func (f *fetcher) Report() *Report {
+	return f.report
+}
+
+// Download the books from the given service. The new books will be downloaded periodically in the watch mode.
+func (f *fetcher) Download(ctx context.Context) error {
+	if f.Watch <= 0 || f.DryRun {
+		return f.download(ctx)
+	}
+
+	failures := 0
+	for {
+		wait := f.Watch
+		if err := f.download(ctx); err != nil && ctx.Err() == nil {
+			// Back off on the consecutive errors.
+			failures++
+			wait = f.Watch << failures
+			if wait > maxWatchBackoff || wait <= 0 {
+				wait = maxWatchBackoff
+			}
+			log.Warnf("The download failed: %v, retry after %s", err, wait)
+		} else {
+			failures = 0
+		}
+
+		if ctx.Err() == nil {
+			log.Infof("Wait %s for the new books.", wait)
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("The watch mode has been stopped.")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// download the books from the given service once.
+func (f *fetcher) download(ctx context.Context) (err error) {
+	// Write the summary of this execution.
+	f.report = newReport(f.Category)
+	if f.ReportPath != "" {
+		defer func() {
+			if e := f.report.Save(f.ReportPath, err); e != nil {
+				log.Warnf("Failed to write the download report: %v", e)
+			}
+		}()
+	}
+
 	// Create the config path.
 	configPath, err := f.ConfigPath()
 	if err != nil {
@@ -44,26 +106,55 @@ func (f *fetcher) Download() error {
 	}
 
 	// Query the total download amount from the given service.
-	size, err := f.service.size()
+	size, err := f.service.size(ctx)
 	if err != nil {
 		return err
 	}
 	log.Infof("Successfully query the download content counts: %d", size)
 
+	// List the books without downloading them.
+	if f.DryRun {
+		return f.list(ctx, size)
+	}
+
 	// Create download progress with rate limit.
 	if f.processFile == "" {
-		if len(f.Keywords) == 0 {
+		if f.Filter.Empty() {
 			f.processFile = defaultProgressFile
 		} else {
-			// Avoid the download progress overloading.
-			f.processFile = strconv.FormatInt(time.Now().Unix(), 10) + defaultProgressFile
+			// Avoid the download progress overloading, the same filters share the progress among the executions.
+			f.processFile = f.Filter.key() + "-" + defaultProgressFile
 		}
 	}
 	rate := f.RateLimit * f.Thread
-	f.progress, err = progress.NewProgress(f.InitialBookID, size, rate, filepath.Join(configPath, f.processFile))
+	if len(f.BookIDs) != 0 {
+		f.progress, err = progress.NewListProgress(f.BookIDs, size, rate)
+	} else {
+		f.progress, err = progress.New(f.Storage, f.InitialBookID, size, rate, filepath.Join(configPath, f.processFile))
+	}
 	if err != nil {
 		return err
 	}
+	f.progress = progress.WithEndBookID(f.progress, f.EndBookID)
+	defer func() {
+		// Flush the download progress before exiting.
+		if err := f.progress.Close(); err != nil {
+			log.Warnf("Failed to save the download progress: %v", err)
+		}
+	}()
+
+	// Only download the failed books in the previous executions.
+	if f.RetryFailed {
+		records, err := f.progress.RetryFailed()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			log.Info("No failed books need to be downloaded again.")
+			return nil
+		}
+		log.Infof("Retry downloading %d failed books.", len(records))
+	}
 
 	// Create the download directory if it's not existed.
 	err = os.MkdirAll(f.DownloadPath, 0o755)
@@ -71,8 +162,42 @@ func (f *fetcher) Download() error {
 		return err
 	}
 
+	// Load the content index for detecting the duplicated books across all the sources.
+	f.index, err = file.OpenIndex(filepath.Join(f.ConfigRoot, contentIndexFile))
+	if err != nil {
+		return err
+	}
+
 	// Create the file creator.
-	f.creator = file.NewCreator(f.Rename, f.DownloadPath, f.Formats, f.Extract)
+	f.creator = file.NewCreator(&file.CreatorConfig{
+		Rename:       f.Rename,
+		NameTemplate: f.NameTemplate,
+		DownloadPath: f.DownloadPath,
+		Formats:      f.Formats,
+		Extract:      f.Extract,
+		Limits:       f.ExtractLimits,
+		Dedupe:       f.Dedupe,
+		Conflict:     f.Conflict,
+		Embed:        f.EmbedMetadata,
+		Index:        f.index,
+
+		ArchiveEncoding: f.ArchiveEncoding,
+	})
+	f.manifest = file.NewManifest(f.DownloadPath)
+	if f.Metadata != file.MetadataNone || f.EmbedMetadata {
+		if f.covers, err = client.New(f.Config.Config); err != nil {
+			return err
+		}
+	}
+	if f.Delivery != nil {
+		f.sender = delivery.NewSender(f.Delivery, filepath.Join(f.ConfigRoot, f.Delivery.QueueFile()))
+		// Send the books which failed in the previous executions.
+		delivered, e := f.sender.Retry(ctx)
+		if e != nil {
+			log.Warnf("Failed to save the delivery queue: %v", e)
+		}
+		f.report.deliver(delivered)
+	}
 
 	// Create the download thread and save the files.
 	f.errs = make(chan error, f.Thread)
@@ -83,7 +208,7 @@ func (f *fetcher) Download() error {
 		wait.Add(1)
 		go func() {
 			defer wait.Done()
-			f.startDownload()
+			f.startDownload(ctx)
 		}()
 	}
 	wait.Wait()
@@ -96,13 +221,23 @@ func (f *fetcher) Download() error {
 		log.Debug("All the fetch thread have been finished.")
 	}
 
+	if ctx.Err() != nil {
+		return ErrDownloadInterrupted
+	}
+
 	return nil
 }
 
 // startDownload will start a download thread.
-func (f *fetcher) startDownload() { //nolint:gocyclo
+func (f *fetcher) startDownload(ctx context.Context) { //nolint:gocyclo
 thread:
 	for {
+		// Stop acquiring new books if the download has been canceled.
+		if ctx.Err() != nil {
+			log.Debugf("The download thread in [%s] service has been canceled.", f.Category)
+			break thread
+		}
+
 		bookID := f.progress.AcquireBookID()
 		if bookID == progress.NoBookToDownload {
 			// Finish this thread.
@@ -114,9 +249,11 @@ thread:
 		// The error will be sent to the channel.
 
 		// Acquire the available file formats
-		formats, err := f.service.formats(bookID)
+		formats, err := f.service.formats(ctx, bookID)
 		if err != nil {
-			f.errs <- err
+			if ctx.Err() == nil {
+				f.errs <- err
+			}
 			break thread
 		}
 		log.Debugf("Book id %d formats: %v.", bookID, formats)
@@ -127,35 +264,71 @@ thread:
 			log.Warnf("[%d/%d] No downloadable files found.", bookID, f.progress.Size())
 		}
 
-		// Filter the name, skip the progress if the name isn't the desired one.
-		if len(formats) != 0 && len(f.Keywords) != 0 {
-			formats = f.filterNames(formats)
+		// Filter the files, skip the progress if the file isn't the desired one.
+		if len(formats) != 0 && !f.Filter.Empty() {
+			formats = f.filterShares(formats)
 			if len(formats) == 0 {
-				log.Warnf("[%d/%d] The files found by the given keywords", bookID, f.progress.Size())
+				log.Warnf("[%d/%d] No files found by the given filters", bookID, f.progress.Size())
+				f.report.filter()
 				// No need to save the download progress.
 				continue
 			}
 		}
+		// The preferred format is chosen from the files which match the filters.
+		formats = f.preferFormats(formats)
 
 		// Download the file by formats one by one.
+		record := &progress.Record{BookID: bookID, Status: progress.Done}
+		if len(formats) == 0 {
+			record.Status = progress.Skipped
+		}
+		var downloaded []file.Output
+		// The formats of the book share the same cover, it's only downloaded once.
+		cover := f.downloadCover(ctx, formats)
 		for format, share := range formats {
-			err := f.downloadFile(bookID, format, share)
-			for retry := 0; err != nil && !errors.Is(err, ErrFileNotExist) && retry < f.Retry; retry++ {
+			outputs, err := f.downloadFile(ctx, bookID, format, share, cover)
+			record.Attempts++
+			for retry := 0; err != nil && retryable(err) && ctx.Err() == nil && retry < f.Retry; retry++ {
 				fmt.Printf("Download book id %d failed: %v, retry (%d/%d)\n", bookID, err, retry, f.Retry)
-				err = f.downloadFile(bookID, format, share)
+				outputs, err = f.downloadFile(ctx, bookID, format, share, cover)
+				record.Attempts++
+			}
+
+			// The book should be downloaded again in the next execution.
+			if ctx.Err() != nil {
+				break thread
 			}
 
 			if err != nil && !errors.Is(err, ErrFileNotExist) {
 				fmt.Printf("Download book id %d failed: %v\n", bookID, err)
+				record.Status = progress.Failed
+				record.LastError = err.Error()
+				f.report.fail(bookID, format, err)
 				if !f.SkipError {
+					// Keep the failed book for retrying it by --retry-failed.
+					f.report.finish(record)
+					if e := f.progress.SaveRecord(record); e != nil {
+						log.Warnf("Failed to save the download progress of the book %d: %v", bookID, e)
+					}
 					f.errs <- err
 					break thread
 				}
 			}
+
+			for _, output := range outputs {
+				record.Files = append(record.Files, output.Path)
+			}
+			downloaded = append(downloaded, outputs...)
+		}
+
+		// Send the book to the e-readers after all the formats have been downloaded.
+		if f.sender != nil && record.Status == progress.Done && len(downloaded) != 0 {
+			f.deliver(ctx, bookID, formats, downloaded)
 		}
 
 		// Save the download progress
-		err = f.progress.SaveBookID(bookID)
+		f.report.finish(record)
+		err = f.progress.SaveRecord(record)
 		if err != nil {
 			f.errs <- err
 			break thread
@@ -163,25 +336,109 @@ thread:
 	}
 }
 
-// downloadFile in a thread.
-func (f *fetcher) downloadFile(bookID int64, format file.Format, share driver.Share) error {
+// downloadFile in a thread. The cover is the cover image of the book, it's empty if it isn't required.
+func (f *fetcher) downloadFile(ctx context.Context, bookID int64, format file.Format, share driver.Share, cover []byte) ([]file.Output, error) {
 	f.progress.TakeRateLimit()
+	if f.HostLimit != nil {
+		f.HostLimit.Take()
+	}
 	log.Debugf("Start download book id %d, format %s, share %v.", bookID, format, share)
 	// Create the file writer.
-	writer, err := f.creator.NewWriter(bookID, f.progress.Size(), share.FileName, share.SubPath, format, share.Size)
+	metadata := file.Metadata{}
+	if share.Metadata != nil {
+		metadata = *share.Metadata
+	}
+	metadata.Source = string(f.Category)
+	metadata.ID = bookID
+	metadata.CoverImage = cover
+	writer, err := f.creator.NewWriter(bookID, f.progress.Size(), share.FileName, share.SubPath, format, share.Size, &metadata)
 	if err != nil {
-		return err
+		return nil, f.skipExisted(bookID, err)
+	}
+
+	// Write file content. Keep the partial file for resuming if the download failed or was canceled.
+	if err := f.service.fetch(ctx, bookID, format, share, writer); err != nil {
+		_ = writer.Abort()
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, f.skipExisted(bookID, err)
+	}
+	f.saveMetadata(&metadata, writer.Outputs())
+
+	outputs := append([]file.Output{}, writer.Outputs()...)
+	f.report.download(format, len(outputs), writer.Written())
+	for i := range outputs {
+		f.runHooks(ctx, bookID, format, &metadata, &outputs[i])
+
+		// Record the checksum for verifying the files in the future.
+		if err := f.manifest.Append(bookID, string(f.Category), outputs[i]); err != nil {
+			log.Warnf("Failed to record the checksum of %s: %v", outputs[i].Path, err)
+		}
+	}
+
+	return outputs, nil
+}
+
+// runHooks will process the downloaded file by the hooks, the output path will be changed if the file is moved.
+// The failures are recorded in the report without stopping the download.
+func (f *fetcher) runHooks(ctx context.Context, bookID int64, format file.Format, metadata *file.Metadata, output *file.Output) {
+	if len(f.Hooks) == 0 {
+		return
+	}
+
+	book := &hook.Book{
+		ID:       bookID,
+		Format:   format,
+		Root:     f.DownloadPath,
+		Path:     output.Path,
+		Size:     output.Size,
+		SHA256:   output.SHA256,
+		Metadata: metadata,
+	}
+	if err := f.Hooks.Run(ctx, book); err != nil {
+		log.Warnf("[%d/%d] %v", bookID, f.progress.Size(), err)
+		f.report.hookFail(bookID, output.Path, err)
 	}
-	defer func() { _ = writer.Close() }()
+	if book.Path != output.Path {
+		// The moved file should still be found by the deduplication.
+		if err := f.index.Move(output.Path, book.Path); err != nil {
+			log.Warnf("Failed to index the moved file %s: %v", book.Path, err)
+		}
+		output.Path = book.Path
+	}
+}
+
+// retryable checks if the failed download could be fixed by downloading it again.
+// The archive which couldn't be extracted is kept, and downloading it again only leaves the renamed copies.
+func retryable(err error) bool {
+	for _, e := range []error{ErrFileNotExist, file.ErrExtractLimit, file.ErrCorruptArchive, file.ErrUnsupportedArchive} {
+		if errors.Is(err, e) {
+			return false
+		}
+	}
+	return true
+}
 
-	// Write file content.
-	return f.service.fetch(bookID, format, share, writer)
+// skipExisted will ignore the error if the file has been existed, the book is treated as downloaded.
+func (f *fetcher) skipExisted(bookID int64, err error) error {
+	if !errors.Is(err, file.ErrFileExisted) {
+		return err
+	}
+	log.Infof("[%d/%d] Skip the download, %v", bookID, f.progress.Size(), err)
+	f.report.exist()
+	return nil
 }
 
 // filterFormats will find the valid formats by user configure.
 func (f *fetcher) filterFormats(formats map[file.Format]driver.Share) map[file.Format]driver.Share {
 	fs := make(map[file.Format]driver.Share)
 	for format, share := range formats {
+		// The unknown formats are accepted if it's required.
+		if f.AnyFormat && !IsValidFormat(format) && format != "" {
+			fs[format] = share
+			continue
+		}
 		for _, vf := range f.Formats {
 			if format == vf {
 				fs[format] = share
@@ -192,23 +449,34 @@ func (f *fetcher) filterFormats(formats map[file.Format]driver.Share) map[file.F
 	return fs
 }
 
-func (f *fetcher) filterNames(formats map[file.Format]driver.Share) map[file.Format]driver.Share {
+// preferFormats will choose the best available format by the preferred order when it's required.
+func (f *fetcher) preferFormats(formats map[file.Format]driver.Share) map[file.Format]driver.Share {
+	if len(f.Prefer) == 0 || len(formats) == 0 {
+		return formats
+	}
+
 	fs := make(map[file.Format]driver.Share)
-	for format, share := range formats {
-		if matchKeywords(share.FileName, f.Keywords) {
+	for _, format := range append(append([]file.Format{}, f.Prefer...), f.Formats...) {
+		if share, ok := formats[format]; ok {
+			fs[format] = share
+			break
+		}
+	}
+	for _, format := range f.AlsoKeep {
+		if share, ok := formats[format]; ok {
 			fs[format] = share
 		}
 	}
 	return fs
 }
 
-func matchKeywords(title string, keywords []string) bool {
-	for _, keyword := range keywords {
-		// Should we support the regular expression?
-		if strings.Contains(title, keyword) {
-			return true
+// filterShares will find the files which match the user's filters.
+func (f *fetcher) filterShares(formats map[file.Format]driver.Share) map[file.Format]driver.Share {
+	fs := make(map[file.Format]driver.Share)
+	for format, share := range formats {
+		if f.Filter.Match(&share) {
+			fs[format] = share
 		}
 	}
-
-	return false
+	return fs
 }
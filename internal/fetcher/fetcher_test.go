@@ -0,0 +1,114 @@
+package fetcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/hook"
+	"github.com/bookstairs/bookhunter/internal/progress"
+)
+
+func TestFetcher_ExtractFailed(t *testing.T) {
+	dir := t.TempDir()
+	books := filepath.Join(dir, "books")
+	f := &fetcher{
+		Config: &Config{
+			Category:      SoBooks,
+			Formats:       []file.Format{file.ZIP, file.EPUB},
+			Extract:       true,
+			DownloadPath:  books,
+			InitialBookID: 1,
+			Thread:        1,
+			RateLimit:     60000,
+			Retry:         3,
+			SkipError:     true,
+			Config:        &client.Config{Host: "example.com", ConfigRoot: filepath.Join(dir, "config")},
+		},
+		service: &stubService{books: map[int64]map[file.Format]driver.Share{
+			1: {file.ZIP: {FileName: "broken", URL: "https://example.com/broken.zip"}},
+		}},
+	}
+	assert.NoError(t, f.Download(context.Background()))
+
+	// The broken archive is downloaded once and kept for checking it manually.
+	entries, err := os.ReadDir(books)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "broken.zip", entries[0].Name())
+	assert.Equal(t, 1, f.Report().Failed)
+	assert.Contains(t, f.Report().Failures[0].Error, file.ErrCorruptArchive.Error())
+}
+
+func TestFetcher_SaveFailedRecord(t *testing.T) {
+	dir := t.TempDir()
+	f := &fetcher{
+		Config: &Config{
+			Category:      SoBooks,
+			Formats:       []file.Format{file.EPUB},
+			DownloadPath:  filepath.Join(dir, "books"),
+			InitialBookID: 1,
+			Thread:        1,
+			RateLimit:     60000,
+			Storage:       progress.BoltStorage,
+			Config:        &client.Config{Host: "example.com", ConfigRoot: filepath.Join(dir, "config")},
+		},
+		service: &stubService{books: map[int64]map[file.Format]driver.Share{
+			1: {file.EPUB: {FileName: "expired"}},
+		}},
+	}
+	assert.Error(t, f.Download(context.Background()))
+
+	// The failed book should be kept for retrying even if the download is aborted.
+	configPath, err := f.ConfigPath()
+	assert.NoError(t, err)
+	p, err := progress.New(progress.BoltStorage, 1, 1, 60000, filepath.Join(configPath, defaultProgressFile))
+	assert.NoError(t, err)
+	defer func() { _ = p.Close() }()
+	records, err := p.RetryFailed()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, progress.Failed, records[0].Status)
+	}
+}
+
+func TestFetcher_DeduplicateMovedBooks(t *testing.T) {
+	dir := t.TempDir()
+	library := filepath.Join(dir, "library")
+	hooks, err := hook.Parse([]string{"move:" + library})
+	assert.NoError(t, err)
+	f := &fetcher{
+		Config: &Config{
+			Category:      Talebook,
+			Formats:       []file.Format{file.EPUB},
+			DownloadPath:  filepath.Join(dir, "books"),
+			InitialBookID: 1,
+			Thread:        1,
+			RateLimit:     60000,
+			SkipError:     true,
+			Dedupe:        file.DedupeSkip,
+			Hooks:         hooks,
+			Config:        &client.Config{Host: "example.com", ConfigRoot: filepath.Join(dir, "config")},
+		},
+		service: &stubService{books: map[int64]map[file.Format]driver.Share{
+			1: {file.EPUB: {FileName: "Dune", URL: "https://example.com/dune.epub"}},
+			2: {file.EPUB: {FileName: "Dune (Deluxe Edition)", URL: "https://example.com/dune.epub"}},
+		}},
+	}
+	assert.NoError(t, f.Download(context.Background()))
+
+	// The second book is identical to the first book which has been moved into the library.
+	entries, err := os.ReadDir(library)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "Dune.epub", entries[0].Name())
+	}
+	_, err = os.Stat(filepath.Join(f.DownloadPath, "Dune (Deluxe Edition).epub"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,197 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/hanzi"
+)
+
+// Filter is used to choose the books by the file name and the metadata exposed by the services.
+// The book will be filtered out if the filtered field isn't exposed by the service.
+type Filter struct {
+	Keywords        []string       // The file name should contain one of the keywords.
+	ExcludeKeywords []string       // The file name shouldn't contain any of the keywords.
+	Include         *regexp.Regexp // The file name should match the regular expression.
+	Exclude         *regexp.Regexp // The file name shouldn't match the regular expression.
+	IgnoreCase      bool           // Match the keywords and metadata in case-insensitive.
+	Pinyin          bool           // Match the keywords and metadata by the pinyin or its initials.
+	MinSize         int64          // The minimal file size in bytes.
+	MaxSize         int64          // The maximal file size in bytes.
+	Authors         []string       // One of the authors should contain one of the given names.
+	Publishers      []string       // The publisher should contain one of the given names.
+	Tags            []string       // One of the tags should contain one of the given tags.
+	After           time.Time      // The book should be published after the given date.
+	Before          time.Time      // The book should be published before the given date.
+}
+
+// Empty means all the books will be downloaded.
+func (f *Filter) Empty() bool {
+	return len(f.Keywords) == 0 && len(f.ExcludeKeywords) == 0 && f.Include == nil && f.Exclude == nil &&
+		f.MinSize == 0 && f.MaxSize == 0 && !f.hasMetadata()
+}
+
+func (f *Filter) hasMetadata() bool {
+	return len(f.Authors) != 0 || len(f.Publishers) != 0 || len(f.Tags) != 0 || !f.After.IsZero() || !f.Before.IsZero()
+}
+
+// Match will check the share by all the filter conditions.
+func (f *Filter) Match(share *driver.Share) bool {
+	name := share.FileName
+	if len(f.Keywords) != 0 && !f.contains([]string{name}, f.Keywords) {
+		return false
+	}
+	if f.contains([]string{name}, f.ExcludeKeywords) {
+		return false
+	}
+	if f.Include != nil && !f.Include.MatchString(name) {
+		return false
+	}
+	if f.Exclude != nil && f.Exclude.MatchString(name) {
+		return false
+	}
+
+	if f.MinSize > 0 || f.MaxSize > 0 {
+		if share.Size <= 0 || share.Size < f.MinSize || (f.MaxSize > 0 && share.Size > f.MaxSize) {
+			return false
+		}
+	}
+
+	if !f.hasMetadata() {
+		return true
+	}
+	m := share.Metadata
+	if m == nil {
+		return false
+	}
+	if len(f.Authors) != 0 && !f.contains(m.Authors, f.Authors) {
+		return false
+	}
+	if len(f.Publishers) != 0 && !f.contains([]string{m.Publisher}, f.Publishers) {
+		return false
+	}
+	if len(f.Tags) != 0 && !f.contains(m.Tags, f.Tags) {
+		return false
+	}
+	if !f.After.IsZero() && (m.Published.IsZero() || m.Published.Before(f.After)) {
+		return false
+	}
+	if !f.Before.IsZero() && (m.Published.IsZero() || m.Published.After(f.Before)) {
+		return false
+	}
+
+	return true
+}
+
+// key returns a stable digest of the filter conditions for naming the download progress of the filtered books.
+func (f *Filter) key() string {
+	expr := func(r *regexp.Regexp) string {
+		if r == nil {
+			return ""
+		}
+		return r.String()
+	}
+
+	digest := sha256.New()
+	_, _ = fmt.Fprintf(digest, "%q %q %q %q %t %t %d %d %q %q %q %s %s",
+		f.Keywords, f.ExcludeKeywords, expr(f.Include), expr(f.Exclude), f.IgnoreCase, f.Pinyin, f.MinSize, f.MaxSize,
+		f.Authors, f.Publishers, f.Tags, f.After.Format(time.DateOnly), f.Before.Format(time.DateOnly))
+	return hex.EncodeToString(digest.Sum(nil)[:8])
+}
+
+// contains will check if one of the values contains one of the keywords.
+func (f *Filter) contains(values, keywords []string) bool {
+	for _, value := range values {
+		value = f.normalize(value)
+		var full, initials string
+		if f.Pinyin {
+			full, initials = hanzi.Pinyin(value)
+		}
+		for _, keyword := range keywords {
+			if keyword == "" {
+				continue
+			}
+			keyword = f.normalize(keyword)
+			if strings.Contains(value, keyword) {
+				return true
+			}
+			// The initials only match from the beginning, the short keyword is a substring of too many initials.
+			if f.Pinyin {
+				if py, _ := hanzi.Pinyin(keyword); strings.Contains(full, py) || strings.HasPrefix(initials, py) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// normalize will unify the full-width and half-width characters, the traditional and simplified Chinese,
+// and the case if it's required.
+func (f *Filter) normalize(s string) string {
+	s = hanzi.Simplify(norm.NFKC.String(s))
+	if f.IgnoreCase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// ParseRegex will compile the regular expression for the file name.
+func ParseRegex(expr string, ignoreCase bool) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+var sizeUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1},
+}
+
+// ParseSize will parse the human-readable size, such as 512KB, 10MB and 1G.
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return 0, nil
+	}
+
+	scale := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, scale = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.scale
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %s", size)
+	}
+	return int64(n * float64(scale)), nil
+}
+
+// ParseDate will parse the date in the form of 2006-01-02.
+func ParseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s, it should be like 2006-01-02", date)
+	}
+	return t, nil
+}
@@ -0,0 +1,154 @@
+package fetcher
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+func TestFilter_Match(t *testing.T) {
+	share := &driver.Share{
+		FileName: "The Go Programming Language（第２版）.epub",
+		Size:     2 << 20,
+		Metadata: &file.Metadata{
+			Title:     "The Go Programming Language",
+			Authors:   []string{"Alan A. A. Donovan", "Brian W. Kernighan"},
+			Publisher: "Addison-Wesley",
+			Tags:      []string{"Programming", "Go"},
+			Published: time.Date(2015, 10, 26, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	include, _ := ParseRegex(`^the go`, true)
+	exclude, _ := ParseRegex(`\.pdf$`, false)
+	after, _ := ParseDate("2015-01-01")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty filter", filter: Filter{}, want: true},
+		{name: "keyword", filter: Filter{Keywords: []string{"Programming"}}, want: true},
+		{name: "keyword with different case", filter: Filter{Keywords: []string{"programming"}}, want: false},
+		{name: "case-insensitive keyword", filter: Filter{Keywords: []string{"programming"}, IgnoreCase: true}, want: true},
+		{name: "full-width keyword", filter: Filter{Keywords: []string{"(第2版)"}}, want: true},
+		{name: "exclude keyword", filter: Filter{ExcludeKeywords: []string{"Language"}}, want: false},
+		{name: "regex", filter: Filter{Include: include, Exclude: exclude}, want: true},
+		{name: "size range", filter: Filter{MinSize: 1 << 20, MaxSize: 3 << 20}, want: true},
+		{name: "too large", filter: Filter{MaxSize: 1 << 20}, want: false},
+		{name: "author", filter: Filter{Authors: []string{"kernighan"}, IgnoreCase: true}, want: true},
+		{name: "publisher", filter: Filter{Publishers: []string{"O'Reilly"}}, want: false},
+		{name: "tag and date", filter: Filter{Tags: []string{"Go"}, After: after}, want: true},
+		{name: "published before", filter: Filter{Before: after}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(share))
+		})
+	}
+
+	chinese := &driver.Share{FileName: "三國演義（第２版）.epub", Metadata: &file.Metadata{Authors: []string{"罗贯中"}}}
+	tests = []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "simplified keyword", filter: Filter{Keywords: []string{"三国演义"}}, want: true},
+		{name: "traditional author", filter: Filter{Authors: []string{"羅貫中"}}, want: true},
+		{name: "pinyin without the flag", filter: Filter{Keywords: []string{"sanguo"}}, want: false},
+		{name: "pinyin", filter: Filter{Keywords: []string{"sanguo"}, Pinyin: true}, want: true},
+		{name: "pinyin initials", filter: Filter{Keywords: []string{"SGYY"}, Pinyin: true}, want: true},
+		{name: "pinyin initials prefix", filter: Filter{Keywords: []string{"sg"}, Pinyin: true}, want: true},
+		{name: "short pinyin initials", filter: Filter{Keywords: []string{"yy"}, Pinyin: true}, want: false},
+		{name: "pinyin initials inside", filter: Filter{Keywords: []string{"gy"}, Pinyin: true}, want: false},
+		{name: "pinyin author", filter: Filter{Authors: []string{"luoguanzhong"}, Pinyin: true}, want: true},
+		{name: "homophone", filter: Filter{Keywords: []string{"三果"}, Pinyin: true}, want: true},
+		{name: "exclude pinyin", filter: Filter{ExcludeKeywords: []string{"yanyi"}, Pinyin: true}, want: false},
+		{name: "wrong pinyin", filter: Filter{Keywords: []string{"shuihu"}, Pinyin: true}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(chinese))
+		})
+	}
+
+	// The metadata filter doesn't match the services which don't expose the metadata.
+	assert.False(t, (&Filter{Tags: []string{"Go"}}).Match(&driver.Share{FileName: share.FileName}))
+	assert.False(t, (&Filter{MinSize: 1}).Match(&driver.Share{FileName: share.FileName}))
+}
+
+func TestFilter_Key(t *testing.T) {
+	include, _ := ParseRegex(`^the go`, true)
+	filter := &Filter{Keywords: []string{"Go"}, Include: include, MaxSize: 1 << 20}
+	key := filter.key()
+	assert.Regexp(t, `^[0-9a-f]{16}$`, key)
+
+	// The same filters share the download progress.
+	include, _ = ParseRegex(`^the go`, true)
+	assert.Equal(t, key, (&Filter{Keywords: []string{"Go"}, Include: include, MaxSize: 1 << 20}).key())
+	assert.NotEqual(t, key, (&Filter{Keywords: []string{"Go"}, MaxSize: 1 << 20}).key())
+	assert.NotEqual(t, key, (&Filter{Keywords: []string{"Go", "Rust"}, Include: include, MaxSize: 1 << 20}).key())
+}
+
+func TestParseSize(t *testing.T) {
+	for size, want := range map[string]int64{"": 0, "100": 100, "512KB": 512 << 10, "1.5m": 3 << 19, "2G": 2 << 30} {
+		got, err := ParseSize(size)
+		assert.NoError(t, err, size)
+		assert.Equal(t, want, got, size)
+	}
+	_, err := ParseSize("ten MB")
+	assert.Error(t, err)
+}
+
+func TestFetcher_PreferFormats(t *testing.T) {
+	formats := map[file.Format]driver.Share{
+		file.MOBI: {FileName: "book.mobi"},
+		file.AZW3: {FileName: "book.azw3"},
+		file.PDF:  {FileName: "book.pdf"},
+	}
+	keys := func(formats map[file.Format]driver.Share) []file.Format {
+		var fs []file.Format
+		for format := range formats {
+			fs = append(fs, format)
+		}
+		slices.Sort(fs)
+		return fs
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		want   []file.Format
+	}{
+		{name: "all formats", config: Config{}, want: []file.Format{file.AZW3, file.MOBI, file.PDF}},
+		{name: "preferred", config: Config{Prefer: []file.Format{file.EPUB, file.AZW3, file.PDF}}, want: []file.Format{file.AZW3}},
+		{name: "also keep", config: Config{Prefer: []file.Format{file.AZW3}, AlsoKeep: []file.Format{file.PDF}}, want: []file.Format{file.AZW3, file.PDF}},
+		{name: "fallback", config: Config{Prefer: []file.Format{file.EPUB}, Formats: []file.Format{file.PDF, file.MOBI}}, want: []file.Format{file.PDF}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fetcher{Config: &tt.config}
+			assert.Equal(t, tt.want, keys(f.preferFormats(formats)))
+		})
+	}
+}
+
+func TestFetcher_FilterFormats(t *testing.T) {
+	formats := map[file.Format]driver.Share{
+		file.EPUB: {FileName: "book.epub"},
+		file.DJVU: {FileName: "book.djvu"},
+		"lit":     {FileName: "book.lit"},
+	}
+
+	f := &fetcher{Config: &Config{Formats: []file.Format{file.EPUB, file.DJVU}}}
+	assert.Len(t, f.filterFormats(formats), 2)
+
+	f.AnyFormat = true
+	assert.Len(t, f.filterFormats(formats), 3)
+	assert.True(t, f.AcceptFormat("lit"))
+}
@@ -1,8 +1,8 @@
 package fetcher
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"sort"
 	"strconv"
 
@@ -203,16 +203,17 @@ func newHsuService(config *Config) (service, error) {
 	return &hsuService{config: config, Client: c, books: books}, nil
 }
 
-func (h *hsuService) size() (int64, error) {
+func (h *hsuService) size(context.Context) (int64, error) {
 	return int64(len(h.books)), nil
 }
 
-func (h *hsuService) formats(i int64) (map[file.Format]driver.Share, error) {
+func (h *hsuService) formats(ctx context.Context, i int64) (map[file.Format]driver.Share, error) {
 	book := h.books[i-1]
 
 	if book != nil {
 		if format, ok := formatMapping[book.Format]; ok {
 			resp, err := h.R().
+				SetContext(ctx).
 				SetQueryParam("seriesId", strconv.Itoa(int(i))).
 				Get("/api/download/series-size")
 			if err != nil {
@@ -232,6 +233,7 @@ func (h *hsuService) formats(i int64) (map[file.Format]driver.Share, error) {
 				format: {
 					FileName: book.Name,
 					Size:     size,
+					Metadata: &file.Metadata{Title: book.Name},
 				},
 			}, nil
 		}
@@ -240,19 +242,6 @@ func (h *hsuService) formats(i int64) (map[file.Format]driver.Share, error) {
 	return make(map[file.Format]driver.Share), nil
 }
 
-func (h *hsuService) fetch(i int64, _ file.Format, _ driver.Share, writer file.Writer) error {
-	resp, err := h.R().
-		SetDoNotParseResponse(true).
-		SetQueryParam("seriesId", strconv.Itoa(int(i))).
-		Get("/api/download/series")
-	if err != nil {
-		return err
-	}
-
-	body := resp.RawBody()
-	defer func() { _ = body.Close() }()
-
-	// Save the download content info files.
-	_, err = io.Copy(writer, body)
-	return err
+func (h *hsuService) fetch(ctx context.Context, i int64, _ file.Format, _ driver.Share, writer file.Writer) error {
+	return downloadContent(ctx, h.Client, "/api/download/series?seriesId="+strconv.Itoa(int(i)), writer)
 }
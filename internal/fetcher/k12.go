@@ -1,8 +1,8 @@
 package fetcher
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"path/filepath"
 	"strings"
 
@@ -31,8 +31,9 @@ type k12Service struct {
 	metadata map[int64]TextBook
 }
 
-func (k *k12Service) size() (int64, error) {
+func (k *k12Service) size(ctx context.Context) (int64, error) {
 	resp, err := k.R().
+		SetContext(ctx).
 		SetResult(&MetadataSource{}).
 		Get(textBookMetadata)
 	if err != nil {
@@ -43,7 +44,7 @@ func (k *k12Service) size() (int64, error) {
 	id := int64(1)
 
 	for _, url := range strings.Split(res.URLs, ",") {
-		books, err := k.downloadMetadata(url)
+		books, err := k.downloadMetadata(ctx, url)
 		if err != nil {
 			return 0, err
 		}
@@ -58,8 +59,8 @@ func (k *k12Service) size() (int64, error) {
 	return int64(len(k.metadata)), nil
 }
 
-func (k *k12Service) downloadMetadata(url string) ([]TextBook, error) {
-	resp, err := k.R().SetResult([]TextBook{}).Get(url)
+func (k *k12Service) downloadMetadata(ctx context.Context, url string) ([]TextBook, error) {
+	resp, err := k.R().SetContext(ctx).SetResult([]TextBook{}).Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -67,15 +68,20 @@ func (k *k12Service) downloadMetadata(url string) ([]TextBook, error) {
 	return *resp.Result().(*[]TextBook), nil
 }
 
-func (k *k12Service) formats(id int64) (map[file.Format]driver.Share, error) {
+func (k *k12Service) formats(_ context.Context, id int64) (map[file.Format]driver.Share, error) {
 	book, ok := k.metadata[id]
 	if !ok {
 		return map[file.Format]driver.Share{}, nil
 	}
 
 	tags := make(map[string]string, len(book.TagList))
+	metadata := &file.Metadata{Title: book.Title, Language: book.Language}
+	if len(book.CustomProperties.Thumbnails) > 0 {
+		metadata.Cover = book.CustomProperties.Thumbnails[0]
+	}
 	for _, tag := range book.TagList {
 		tags[tag.TagID] = tag.TagName
+		metadata.Tags = append(metadata.Tags, tag.TagName)
 	}
 
 	subPath := ""
@@ -96,22 +102,13 @@ func (k *k12Service) formats(id int64) (map[file.Format]driver.Share, error) {
 			Size:       book.CustomProperties.Size,
 			URL:        book.ID,
 			Properties: nil,
+			Metadata:   metadata,
 		},
 	}, nil
 }
 
-func (k *k12Service) fetch(_ int64, _ file.Format, share driver.Share, writer file.Writer) error {
-	resp, err := k.R().SetDoNotParseResponse(true).Get(fmt.Sprintf(downloadLinkTmpl, share.URL))
-	if err != nil {
-		return err
-	}
-
-	body := resp.RawBody()
-	defer func() { _ = body.Close() }()
-
-	// Save the download content info files.
-	_, err = io.Copy(writer, body)
-	return err
+func (k *k12Service) fetch(ctx context.Context, _ int64, _ file.Format, share driver.Share, writer file.Writer) error {
+	return downloadContent(ctx, k.Client, fmt.Sprintf(downloadLinkTmpl, share.URL), writer)
 }
 
 type MetadataSource struct {
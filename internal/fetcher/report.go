@@ -0,0 +1,164 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/progress"
+)
+
+// Report is the machine-readable summary of a download execution.
+type Report struct {
+	Category   Category                     `json:"category"`
+	Start      time.Time                    `json:"start"`
+	End        time.Time                    `json:"end"`
+	Elapsed    string                       `json:"elapsed"`
+	Downloaded int                          `json:"downloaded"` // The books which have been downloaded.
+	Skipped    int                          `json:"skipped"`    // The books which have no downloadable files.
+	Filtered   int                          `json:"filtered"`   // The books which don't match the keywords.
+	Existed    int                          `json:"existed"`    // The files which have been existed in the download path.
+	Failed     int                          `json:"failed"`     // The books which couldn't be downloaded.
+	Bytes      int64                        `json:"bytes"`      // The bytes downloaded in this execution.
+	Formats    map[file.Format]*FormatTotal `json:"formats"`
+	Failures   []Failure                    `json:"failures"`
+	Error      string                       `json:"error,omitempty"` // The error which stops the download.
+
+	// The failed post-download hooks, the books are still treated as downloaded.
+	HookFailures []HookFailure `json:"hook_failures"`
+
+	// The books which have been sent by SMTP, and the failed ones which are queued for the next execution.
+	Delivered int `json:"delivered"`
+	Queued    int `json:"queued"`
+
+	lock sync.Mutex
+}
+
+// HookFailure is a downloaded file which couldn't be processed by the hooks.
+type HookFailure struct {
+	BookID int64  `json:"id"`
+	Path   string `json:"path"`
+	Error  string `json:"error"`
+}
+
+// FormatTotal is the downloaded files of a format and the bytes downloaded in this execution.
+type FormatTotal struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Failure is a failed download with its error message.
+type Failure struct {
+	BookID int64       `json:"id"`
+	Format file.Format `json:"format"`
+	Error  string      `json:"error"`
+}
+
+func newReport(category Category) *Report {
+	return &Report{
+		Category:     category,
+		Start:        time.Now(),
+		Formats:      map[file.Format]*FormatTotal{},
+		Failures:     []Failure{},
+		HookFailures: []HookFailure{},
+	}
+}
+
+// filter records a book which doesn't match the keywords.
+func (r *Report) filter() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.Filtered++
+}
+
+// exist records a file which isn't downloaded for the existing file.
+func (r *Report) exist() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.Existed++
+}
+
+// download records the saved files of a downloaded file and the bytes written in this execution.
+// The resumed content isn't counted, and the saved files may be larger or smaller after the extraction.
+func (r *Report) download(format file.Format, files int, bytes int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	total, ok := r.Formats[format]
+	if !ok {
+		total = new(FormatTotal)
+		r.Formats[format] = total
+	}
+	total.Files += files
+	total.Bytes += bytes
+	r.Bytes += bytes
+}
+
+// fail records a failed download.
+func (r *Report) fail(bookID int64, format file.Format, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.Failures = append(r.Failures, Failure{BookID: bookID, Format: format, Error: err.Error()})
+}
+
+// hookFail records a downloaded file which failed in the hooks.
+func (r *Report) hookFail(bookID int64, path string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.HookFailures = append(r.HookFailures, HookFailure{BookID: bookID, Path: path, Error: err.Error()})
+}
+
+// deliver records the books which have been sent by SMTP.
+func (r *Report) deliver(books int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.Delivered += books
+}
+
+// queue records a book which failed to be sent by SMTP.
+func (r *Report) queue() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.Queued++
+}
+
+// finish records the final state of a book.
+func (r *Report) finish(record *progress.Record) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch record.Status {
+	case progress.Done:
+		r.Downloaded++
+	case progress.Skipped:
+		r.Skipped++
+	case progress.Failed:
+		r.Failed++
+	}
+}
+
+// Save will write the report into the given path as JSON.
+func (r *Report) Save(path string, err error) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.End = time.Now()
+	r.Elapsed = r.End.Sub(r.Start).Round(time.Millisecond).String()
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0o644)
+}
@@ -0,0 +1,68 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/hook"
+)
+
+func TestFetcher_Report(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+	hooks, err := hook.Parse([]string{"exec:" + filepath.Join(dir, "missing-command")})
+	assert.NoError(t, err)
+	f := &fetcher{
+		Config: &Config{
+			Category:      Talebook,
+			Formats:       []file.Format{file.EPUB, file.PDF},
+			Filter:        Filter{Keywords: []string{"Action"}},
+			DownloadPath:  filepath.Join(dir, "books"),
+			InitialBookID: 1,
+			Thread:        1,
+			RateLimit:     60000,
+			SkipError:     true,
+			ReportPath:    path,
+			Hooks:         hooks,
+			Config:        &client.Config{Host: "example.com", ConfigRoot: filepath.Join(dir, "config")},
+		},
+		service: &stubService{books: map[int64]map[file.Format]driver.Share{
+			1: {file.EPUB: {FileName: "Go in Action", URL: "https://example.com/1.epub"}},
+			2: {file.PDF: {FileName: "Rust in Action"}},
+			3: {file.EPUB: {FileName: "The Go Programming Language", URL: "https://example.com/3.epub"}},
+			4: {file.MOBI: {FileName: "Kotlin in Action", URL: "https://example.com/4.mobi"}},
+		}},
+	}
+	// The first book has been partially downloaded in the previous execution.
+	assert.NoError(t, os.MkdirAll(f.DownloadPath, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(f.DownloadPath, "Go in Action.epub.part"), []byte("https://"), 0o644))
+	assert.NoError(t, f.Download(context.Background()))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	report := new(Report)
+	assert.NoError(t, json.Unmarshal(content, report))
+
+	assert.Equal(t, Talebook, report.Category)
+	assert.Equal(t, 1, report.Downloaded)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.Filtered)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, int64(len("example.com/1.epub")), report.Bytes, "the resumed content shouldn't be counted")
+	assert.Equal(t, &FormatTotal{Files: 1, Bytes: report.Bytes}, report.Formats[file.EPUB])
+	assert.NotContains(t, report.Formats, file.PDF, "the failed format shouldn't be counted")
+	assert.Equal(t, []Failure{{BookID: 2, Format: file.PDF, Error: "the download link is expired"}}, report.Failures)
+	assert.Empty(t, report.Error)
+
+	// The failed hook doesn't fail the download.
+	assert.Len(t, report.HookFailures, 1)
+	assert.Equal(t, int64(1), report.HookFailures[0].BookID)
+}
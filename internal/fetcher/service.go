@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/bookstairs/bookhunter/internal/driver"
@@ -10,13 +11,13 @@ import (
 // service is a real implementation for fetcher.
 type service interface {
 	// size is the total download amount of this given service.
-	size() (int64, error)
+	size(context.Context) (int64, error)
 
 	// formats will query the available downloadable file formats.
-	formats(int64) (map[file.Format]driver.Share, error)
+	formats(context.Context, int64) (map[file.Format]driver.Share, error)
 
 	// fetch the given book ID.
-	fetch(int64, file.Format, driver.Share, file.Writer) error
+	fetch(context.Context, int64, file.Format, driver.Share, file.Writer) error
 }
 
 // newService is the endpoint for creating all the supported download service.
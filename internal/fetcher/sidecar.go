@@ -0,0 +1,85 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+// maxCoverSize is the max size of the cover image, the larger image isn't a valid cover.
+const maxCoverSize = 20 << 20
+
+// coverExtensions are the accepted image extensions, the .jpg is used for the others.
+var coverExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
+
+// saveMetadata will write the metadata sidecar and the cover image next to the downloaded files.
+// The failures are only logged because the books have been downloaded.
+func (f *fetcher) saveMetadata(metadata *file.Metadata, outputs []file.Output) {
+	if f.Metadata == file.MetadataNone {
+		return
+	}
+
+	for _, output := range outputs {
+		coverPath := ""
+		if len(metadata.CoverImage) > 0 {
+			coverPath = file.SidecarPath(output.Path, coverExtension(metadata.Cover))
+			if err := os.WriteFile(coverPath, metadata.CoverImage, 0o644); err != nil {
+				log.Warnf("Failed to save the cover %s: %v", coverPath, err)
+				coverPath = ""
+			}
+		}
+
+		if _, err := file.SaveSidecar(output.Path, f.Metadata, metadata, coverPath); err != nil {
+			log.Warnf("Failed to save the metadata of %s: %v", output.Path, err)
+		}
+	}
+}
+
+// downloadCover will load the cover image of the book if it's required, the relative link is resolved by the website.
+// All the formats of the book share the same cover. The book is still saved without the cover if the download failed.
+func (f *fetcher) downloadCover(ctx context.Context, formats map[file.Format]driver.Share) []byte {
+	if f.covers == nil {
+		return nil
+	}
+
+	for _, share := range formats {
+		metadata := share.Metadata
+		if metadata == nil || metadata.Cover == "" {
+			continue
+		}
+
+		resp, err := f.covers.R().
+			SetContext(ctx).
+			SetResponseBodyLimit(maxCoverSize).
+			Get(metadata.Cover)
+		if err == nil && resp.IsError() {
+			err = fmt.Errorf("unexpected cover response status: %s", resp.Status())
+		}
+		if err != nil {
+			log.Warnf("Failed to download the cover of %s: %v", metadata.Title, err)
+			return nil
+		}
+
+		return resp.Body()
+	}
+
+	return nil
+}
+
+// coverExtension will find the image extension from the link.
+func coverExtension(link string) string {
+	if u, err := url.Parse(link); err == nil {
+		link = u.Path
+	}
+	if ext := strings.ToLower(path.Ext(link)); coverExtensions[ext] {
+		return ext
+	}
+	return ".jpg"
+}
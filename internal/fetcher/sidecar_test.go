@@ -0,0 +1,88 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+func TestFetcher_SaveMetadata(t *testing.T) {
+	requests := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/covers/1.png" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests.Add(1)
+		_, _ = w.Write([]byte("cover image"))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	dir := t.TempDir()
+	dune := &file.Metadata{
+		Title:   "Dune",
+		Authors: []string{"Frank Herbert"},
+		Cover:   "/covers/1.png?t=1",
+	}
+	f := &fetcher{
+		Config: &Config{
+			Category:      Talebook,
+			Formats:       []file.Format{file.EPUB, file.MOBI},
+			DownloadPath:  dir,
+			InitialBookID: 1,
+			Thread:        1,
+			RateLimit:     60000,
+			SkipError:     true,
+			Metadata:      file.MetadataJSON,
+			Config:        &client.Config{Host: u.Host, ConfigRoot: filepath.Join(dir, "config")},
+		},
+		service: &stubService{books: map[int64]map[file.Format]driver.Share{
+			1: {
+				file.EPUB: {FileName: "Dune", URL: "https://example.com/1.epub", Metadata: dune},
+				file.MOBI: {FileName: "Dune", URL: "https://example.com/1.mobi", Metadata: dune},
+			},
+			2: {file.EPUB: {FileName: "Emma", URL: "https://example.com/2.epub", Metadata: &file.Metadata{
+				Title: "Emma",
+				Cover: "/covers/2.png",
+			}}},
+		}},
+	}
+	assert.NoError(t, f.Download(context.Background()))
+
+	content, err := os.ReadFile(filepath.Join(dir, "Dune.json"))
+	assert.NoError(t, err)
+	metadata := new(file.Metadata)
+	assert.NoError(t, json.Unmarshal(content, metadata))
+	assert.Equal(t, &file.Metadata{
+		Title:   "Dune",
+		Authors: []string{"Frank Herbert"},
+		Source:  string(Talebook),
+		ID:      1,
+		Cover:   "/covers/1.png?t=1",
+	}, metadata)
+
+	cover, err := os.ReadFile(filepath.Join(dir, "Dune.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, "cover image", string(cover))
+	// The cover is downloaded once for all the formats of the book.
+	assert.Equal(t, int32(1), requests.Load())
+
+	// The missing cover doesn't fail the download.
+	_, err = os.Stat(filepath.Join(dir, "Emma.json"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "Emma.png"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -1,8 +1,8 @@
 package fetcher
 
 import (
+	"context"
 	"crypto/tls"
-	"io"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -52,8 +52,9 @@ func newSobooksService(config *Config) (service, error) {
 	return &sobooksService{config: config, Client: c, driver: d}, nil
 }
 
-func (s *sobooksService) size() (int64, error) {
+func (s *sobooksService) size(ctx context.Context) (int64, error) {
 	resp, err := s.R().
+		SetContext(ctx).
 		Get("/")
 	if err != nil {
 		return 0, err
@@ -83,8 +84,9 @@ func (s *sobooksService) size() (int64, error) {
 	return int64(lastID), nil
 }
 
-func (s *sobooksService) formats(id int64) (map[file.Format]driver.Share, error) {
+func (s *sobooksService) formats(ctx context.Context, id int64) (map[file.Format]driver.Share, error) {
 	resp, err := s.R().
+		SetContext(ctx).
 		SetPathParam("bookId", strconv.FormatInt(id, 10)).
 		SetHeader("referer", s.BaseURL).
 		Get("/books/{bookId}.html")
@@ -101,20 +103,27 @@ func (s *sobooksService) formats(id int64) (map[file.Format]driver.Share, error)
 		return map[file.Format]driver.Share{}, nil
 	}
 
+	metadata, err := sobooks.ParseMetadata(resp.String())
+	if err != nil {
+		return nil, err
+	}
+	metadata.Title = title
+
 	res := make(map[file.Format]driver.Share)
 	for source, link := range links {
 		if source != s.driver.Source() {
 			continue
 		}
 
-		shares, err := s.driver.Resolve(link.URL, link.Code)
+		shares, err := s.driver.Resolve(ctx, link.URL, link.Code)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, share := range shares {
+			share.Metadata = metadata
 			if ext, has := file.LinkExtension(share.FileName); has {
-				if IsValidFormat(ext) {
+				if s.config.AcceptFormat(ext) {
 					res[ext] = share
 				} else {
 					log.Debugf("The file name %s don't have valid extension %s", share.FileName, ext)
@@ -130,31 +139,17 @@ func (s *sobooksService) formats(id int64) (map[file.Format]driver.Share, error)
 			FileName: title + ".epub",
 			Size:     0,
 			URL:      link.URL,
+			Metadata: metadata,
 		}
 	}
 	return res, nil
 }
 
-func (s *sobooksService) fetch(_ int64, _ file.Format, share driver.Share, writer file.Writer) error {
+func (s *sobooksService) fetch(ctx context.Context, _ int64, _ file.Format, share driver.Share, writer file.Writer) error {
 	u, err := url.Parse(share.URL)
 	if err != nil {
 		return err
 	}
-	resp, err := s.R().
-		SetDoNotParseResponse(true).
-		Get(u.String())
-	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode() == 404 {
-		return ErrFileNotExist
-	}
-	body := resp.RawBody()
-	defer func() { _ = body.Close() }()
 
-	// Save the download content info files.
-	writer.SetSize(resp.RawResponse.ContentLength)
-	_, err = io.Copy(writer, body)
-	return err
+	return downloadContent(ctx, s.Client, u.String(), writer)
 }
@@ -1,12 +1,14 @@
 package fetcher
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/bookstairs/bookhunter/internal/client"
 	"github.com/bookstairs/bookhunter/internal/driver"
@@ -29,6 +31,7 @@ var (
 
 type talebookService struct {
 	config *Config
+	sender atomic.Value // The kindle sender address of the talebook website.
 	*client.Client
 }
 
@@ -73,8 +76,9 @@ func newTalebookService(config *Config) (service, error) {
 	return &talebookService{config: config, Client: c}, nil
 }
 
-func (t *talebookService) size() (int64, error) {
+func (t *talebookService) size(ctx context.Context) (int64, error) {
 	resp, err := t.R().
+		SetContext(ctx).
 		SetResult(&talebook.BooksResp{}).
 		Get("/api/recent")
 	if err != nil {
@@ -100,8 +104,9 @@ func (t *talebookService) size() (int64, error) {
 	return bookID, nil
 }
 
-func (t *talebookService) formats(id int64) (map[file.Format]driver.Share, error) {
+func (t *talebookService) formats(ctx context.Context, id int64) (map[file.Format]driver.Share, error) {
 	resp, err := t.R().
+		SetContext(ctx).
 		SetResult(&talebook.BookResp{}).
 		SetPathParam("bookID", strconv.FormatInt(id, 10)).
 		Get("/api/book/{bookID}")
@@ -112,15 +117,37 @@ func (t *talebookService) formats(id int64) (map[file.Format]driver.Share, error
 	result := resp.Result().(*talebook.BookResp)
 	switch result.Err {
 	case talebook.SuccessStatus:
+		if result.KindleSender != "" {
+			t.sender.Store(result.KindleSender)
+		}
+		metadata := &file.Metadata{
+			Title:     result.Book.Title,
+			Authors:   result.Book.Authors,
+			Publisher: result.Book.Publisher,
+			Tags:      result.Book.Tags,
+			Series:    result.Book.Series,
+			ISBN:      result.Book.ISBN,
+			Language:  result.Book.Language,
+			Cover:     result.Book.Img,
+
+			Description: result.Book.Comments,
+		}
+		if len(result.Book.PubDate) >= len(time.DateOnly) {
+			metadata.Published, _ = time.Parse(time.DateOnly, result.Book.PubDate[:len(time.DateOnly)])
+		}
+
 		formats := make(map[file.Format]driver.Share)
 		for _, f := range result.Book.Files {
-			format, err := ParseFormat(strings.ToLower(f.Format))
-			if err != nil {
-				return nil, err
+			format := file.Format(strings.ToLower(f.Format))
+			if !t.config.AcceptFormat(format) {
+				log.Warnf("Skip the unsupported format %s of the book %d", f.Format, id)
+				continue
 			}
 			formats[format] = driver.Share{
 				FileName: fmt.Sprintf("%s.%s", result.Book.Title, format),
+				Size:     f.Size,
 				URL:      f.Href,
+				Metadata: metadata,
 			}
 		}
 		return formats, nil
@@ -131,17 +158,12 @@ func (t *talebookService) formats(id int64) (map[file.Format]driver.Share, error
 	}
 }
 
-func (t *talebookService) fetch(_ int64, _ file.Format, share driver.Share, writer file.Writer) error {
-	resp, err := t.R().
-		SetDoNotParseResponse(true).
-		Get(share.URL)
-	if err != nil {
-		return err
-	}
-	body := resp.RawBody()
-	defer func() { _ = body.Close() }()
+func (t *talebookService) fetch(ctx context.Context, _ int64, _ file.Format, share driver.Share, writer file.Writer) error {
+	return downloadContent(ctx, t.Client, share.URL, writer)
+}
 
-	// Save the download content info files.
-	_, err = io.Copy(writer, body)
-	return err
+// mailSender returns the kindle sender address of the website, it's used for delivering the books.
+func (t *talebookService) mailSender() string {
+	sender, _ := t.sender.Load().(string)
+	return sender
 }
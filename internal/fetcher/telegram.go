@@ -1,11 +1,11 @@
 package fetcher
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gotd/td/tg"
 
@@ -32,8 +32,8 @@ func newTelegramService(config *Config) (service, error) {
 
 	// Change the process file name.
 	config.processFile = strings.ReplaceAll(channelID, "/", "_") + ".db"
-	if len(config.Keywords) != 0 {
-		config.processFile = strconv.FormatInt(time.Now().Unix(), 10) + config.processFile
+	if !config.Filter.Empty() {
+		config.processFile = config.Filter.key() + "-" + config.processFile
 	}
 
 	tel, err := telegram.New(channelID, mobile, appID, appHash, sessionPath, config.Proxy)
@@ -50,8 +50,8 @@ type telegramService struct {
 	info     *telegram.ChannelInfo
 }
 
-func (s *telegramService) size() (int64, error) {
-	info, err := s.telegram.ChannelInfo()
+func (s *telegramService) size(ctx context.Context) (int64, error) {
+	info, err := s.telegram.ChannelInfo(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -60,8 +60,8 @@ func (s *telegramService) size() (int64, error) {
 	return info.LastMsgID, nil
 }
 
-func (s *telegramService) formats(id int64) (map[file.Format]driver.Share, error) {
-	files, err := s.telegram.ParseMessage(s.info, id)
+func (s *telegramService) formats(ctx context.Context, id int64) (map[file.Format]driver.Share, error) {
+	files, err := s.telegram.ParseMessage(ctx, s.info, id)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +71,7 @@ func (s *telegramService) formats(id int64) (map[file.Format]driver.Share, error
 		res[f.Format] = driver.Share{
 			FileName: f.Name,
 			Size:     f.Size,
+			Metadata: &file.Metadata{Title: strings.TrimSuffix(f.Name, filepath.Ext(f.Name))},
 			Properties: map[string]any{
 				"fileID":   f.ID,
 				"document": f.Document,
@@ -81,7 +82,7 @@ func (s *telegramService) formats(id int64) (map[file.Format]driver.Share, error
 	return res, nil
 }
 
-func (s *telegramService) fetch(_ int64, f file.Format, share driver.Share, writer file.Writer) error {
+func (s *telegramService) fetch(ctx context.Context, _ int64, f file.Format, share driver.Share, writer file.Writer) error {
 	o := &telegram.File{
 		ID:       share.Properties["fileID"].(int64),
 		Name:     share.FileName,
@@ -90,5 +91,12 @@ func (s *telegramService) fetch(_ int64, f file.Format, share driver.Share, writ
 		Document: share.Properties["document"].(*tg.InputDocumentFileLocation),
 	}
 
-	return s.telegram.DownloadFile(o, writer)
+	// The telegram downloader doesn't support resuming, download the file from the beginning.
+	if writer.Offset() > 0 {
+		if err := writer.Reset(); err != nil {
+			return err
+		}
+	}
+
+	return s.telegram.DownloadFile(ctx, o, writer)
 }
@@ -0,0 +1,69 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/client"
+	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+// growingService will publish a new book after a failed query.
+type growingService struct {
+	*stubService
+	sizes  []int64
+	cancel context.CancelFunc
+}
+
+func (s *growingService) size(context.Context) (int64, error) {
+	if len(s.sizes) == 0 {
+		s.cancel()
+		return 0, context.Canceled
+	}
+	size := s.sizes[0]
+	s.sizes = s.sizes[1:]
+	if size == 0 {
+		return 0, errors.New("the website is unavailable")
+	}
+	return size, nil
+}
+
+func TestFetcher_Watch(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := &fetcher{
+		Config: &Config{
+			Formats:       []file.Format{file.EPUB},
+			DownloadPath:  dir,
+			InitialBookID: 1,
+			Thread:        1,
+			RateLimit:     60000,
+			SkipError:     true,
+			Watch:         10 * time.Millisecond,
+			Config:        &client.Config{Host: "example.com", ConfigRoot: filepath.Join(dir, "config")},
+		},
+		service: &growingService{
+			stubService: &stubService{books: map[int64]map[file.Format]driver.Share{
+				1: {file.EPUB: {FileName: "first", URL: "https://example.com/1.epub"}},
+				2: {file.EPUB: {FileName: "second", URL: "https://example.com/2.epub"}},
+			}},
+			sizes:  []int64{1, 0, 2},
+			cancel: cancel,
+		},
+	}
+
+	assert.NoError(t, f.Download(ctx))
+	for _, name := range []string{"first.epub", "second.epub"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+	}
+}
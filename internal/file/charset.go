@@ -0,0 +1,124 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// AutoEncoding means the encoding of the file names in the archive will be detected.
+const AutoEncoding = "auto"
+
+// zipUTF8Flag is the general purpose bit 11, the file name is encoded in UTF-8.
+const zipUTF8Flag = 0x800
+
+// defaultEncoding is used when the encoding can't be detected, most of the archives are created on the Chinese Windows.
+var defaultEncoding encoding.Encoding = simplifiedchinese.GB18030
+
+// ParseArchiveEncoding will find the encoding by its name, such as gbk, big5 and shift_jis.
+// The auto or empty name will return nil for detecting the encoding.
+func ParseArchiveEncoding(name string) (encoding.Encoding, error) {
+	if name == "" || strings.EqualFold(name, AutoEncoding) {
+		return nil, nil
+	}
+
+	e, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive encoding %s, it should be auto or a charset like gbk, big5 and shift_jis", name)
+	}
+	return e, nil
+}
+
+// charset is the candidate encoding for detecting the file names.
+type charset struct {
+	encoding encoding.Encoding
+
+	// char returns the size of the non-ASCII character at the beginning of the bytes, and whether it's a frequently
+	// used character in this charset, such as the punctuations, kana and the first level Chinese characters.
+	char func(b []byte) (int, bool)
+}
+
+var charsets = []charset{
+	{
+		encoding: simplifiedchinese.GB18030,
+		char: func(b []byte) (int, bool) {
+			if len(b) < 2 {
+				return 1, false
+			}
+			if b[1] >= 0x30 && b[1] <= 0x39 {
+				return 4, false
+			}
+			return 2, (b[0] >= 0xb0 && b[0] <= 0xd7 || b[0] == 0xa1 || b[0] == 0xa3) && b[1] >= 0xa1 && b[1] <= 0xfe
+		},
+	},
+	{
+		encoding: traditionalchinese.Big5,
+		char: func(b []byte) (int, bool) {
+			if len(b) < 2 {
+				return 1, false
+			}
+			return 2, b[0] >= 0xa1 && b[0] <= 0xc6 && (b[1] >= 0x40 && b[1] <= 0x7e || b[1] >= 0xa1 && b[1] <= 0xfe)
+		},
+	},
+	{
+		encoding: japanese.ShiftJIS,
+		char: func(b []byte) (int, bool) {
+			// The half-width katakana are single byte.
+			if len(b) < 2 || b[0] >= 0xa1 && b[0] <= 0xdf {
+				return 1, false
+			}
+			return 2, b[0] >= 0x81 && b[0] <= 0x9f && b[1] >= 0x40 && b[1] <= 0xfc && b[1] != 0x7f
+		},
+	},
+}
+
+// detectEncoding will guess the encoding of the file names which aren't flagged as UTF-8. The names are treated as
+// UTF-8 if they are all valid, or the charset with the most frequently used characters will be chosen.
+// It returns nil if the names are ASCII.
+func detectEncoding(names []string) encoding.Encoding {
+	content := []byte(strings.Join(names, ""))
+	ascii := true
+	for _, b := range content {
+		if b >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return nil
+	}
+	if utf8.Valid(content) {
+		return unicode.UTF8
+	}
+
+	var detected encoding.Encoding
+	best := -1.0
+	for _, c := range charsets {
+		total, common := 0, 0
+		for i := 0; i < len(content); {
+			if content[i] < utf8.RuneSelf {
+				i++
+				continue
+			}
+			size, ok := c.char(content[i:])
+			total++
+			if ok {
+				common++
+			}
+			i += size
+		}
+
+		if score := float64(common) / float64(total); score > best {
+			detected, best = c.encoding, score
+		}
+	}
+
+	return detected
+}
@@ -0,0 +1,66 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encodeNames(t *testing.T, e encoding.Encoding, names ...string) []string {
+	var encoded []string
+	for _, name := range names {
+		s, err := e.NewEncoder().String(name)
+		assert.NoError(t, err)
+		encoded = append(encoded, s)
+	}
+	return encoded
+}
+
+func TestDetectEncoding(t *testing.T) {
+	assert.Nil(t, detectEncoding([]string{"book.epub", "cover.jpg"}))
+	assert.Equal(t, unicode.UTF8, detectEncoding([]string{"三体.epub", "book.epub"}))
+	assert.Equal(t, simplifiedchinese.GB18030, detectEncoding(encodeNames(t, simplifiedchinese.GB18030, "三体 地球往事.epub", "《红楼梦》.mobi")))
+	assert.Equal(t, traditionalchinese.Big5, detectEncoding(encodeNames(t, traditionalchinese.Big5, "三體 地球往事.epub", "《紅樓夢》.mobi")))
+	assert.Equal(t, japanese.ShiftJIS, detectEncoding(encodeNames(t, japanese.ShiftJIS, "ノルウェイの森 村上春樹.epub")))
+}
+
+func TestParseArchiveEncoding(t *testing.T) {
+	e, err := ParseArchiveEncoding(AutoEncoding)
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+
+	e, err = ParseArchiveEncoding("big5")
+	assert.NoError(t, err)
+	assert.Equal(t, traditionalchinese.Big5, e)
+
+	_, err = ParseArchiveEncoding("unknown")
+	assert.Error(t, err)
+}
+
+func TestWriter_ExtractEncodedNames(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	names := append(encodeNames(t, traditionalchinese.Big5, "三體.epub"), "紅樓夢.epub")
+	for _, name := range names {
+		// The UTF-8 flag will be set for the valid UTF-8 names.
+		_, err := w.CreateHeader(&zip.FileHeader{Name: name, NonUTF8: name == names[0]})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	root := t.TempDir()
+	extractArchive(t, root, ZIP, buf.Bytes())
+	for _, name := range []string{"三體.epub", "紅樓夢.epub"} {
+		_, err := os.Stat(filepath.Join(root, name))
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,74 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Conflict string // The policy for the existing file which has the same name with the downloading file.
+
+const (
+	ConflictSkip      Conflict = "skip"      // Skip the download and keep the existing file.
+	ConflictOverwrite Conflict = "overwrite" // Replace the existing file after the download.
+	ConflictRename    Conflict = "rename"    // Save the downloaded file with a " (1)" like suffix.
+	ConflictCompare   Conflict = "compare"   // Skip if the existing file is identical, or rename the downloaded file.
+)
+
+var ErrFileExisted = errors.New("the file has been existed")
+
+// ParseConflict will create the conflict policy from the string.
+func ParseConflict(policy string) (Conflict, error) {
+	switch c := Conflict(strings.ToLower(policy)); c {
+	case ConflictSkip, ConflictOverwrite, ConflictRename, ConflictCompare:
+		return c, nil
+	case "":
+		return ConflictRename, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy %s, it should be one of skip, overwrite, rename or compare", policy)
+	}
+}
+
+// resolve will choose the file path for the download by the policy. The existing path is returned if the downloaded
+// file should be compared with it after the download.
+func (c Conflict) resolve(path string, size int64) (string, string, error) {
+	// The partial file is always resumed.
+	if _, err := os.Stat(path + partSuffix); err == nil {
+		return path, "", nil
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return path, "", nil
+	}
+
+	switch c {
+	case ConflictSkip:
+		return "", "", fmt.Errorf("%w: %s", ErrFileExisted, path)
+	case ConflictOverwrite:
+		// The completed file is renamed to replace the existing file, which is kept if the download fails.
+		return path, "", nil
+	case ConflictCompare:
+		if size > 0 {
+			if stat.Size() == size {
+				return "", "", fmt.Errorf("%w: %s", ErrFileExisted, path)
+			}
+			return UniquePath(path), "", nil
+		}
+		// The size is unknown, compare the checksum after the download.
+		return UniquePath(path), path, nil
+	default:
+		return UniquePath(path), "", nil
+	}
+}
+
+// sameFile checks if the file has the given SHA-256 checksum.
+func sameFile(path string, checksum []byte) bool {
+	h := sha256.New()
+	if err := hashFile(h, path); err != nil {
+		return false
+	}
+	return bytes.Equal(h.Sum(nil), checksum)
+}
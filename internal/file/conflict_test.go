@@ -0,0 +1,117 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter_Conflict(t *testing.T) {
+	existing := []byte("the curated book")
+
+	for policy, expected := range map[Conflict][]string{
+		ConflictSkip:      {"the curated book"},
+		ConflictOverwrite: {"the new book"},
+		ConflictRename:    {"the curated book", "the new book"},
+		ConflictCompare:   {"the curated book", "the new book"},
+	} {
+		t.Run(string(policy), func(t *testing.T) {
+			root := t.TempDir()
+			assert.NoError(t, os.WriteFile(filepath.Join(root, "book.epub"), existing, 0o644))
+			c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Conflict: policy})
+
+			w, err := c.NewWriter(1, 1, "book", "", EPUB, 12, nil)
+			if policy == ConflictSkip {
+				assert.ErrorIs(t, err, ErrFileExisted)
+			} else {
+				assert.NoError(t, err)
+				_, err = w.Write([]byte("the new book"))
+				assert.NoError(t, err)
+				assert.NoError(t, w.Close())
+			}
+
+			var contents []string
+			for _, name := range []string{"book.epub", "book (1).epub"} {
+				if content, err := os.ReadFile(filepath.Join(root, name)); err == nil {
+					contents = append(contents, string(content))
+				}
+			}
+			assert.Equal(t, expected, contents)
+		})
+	}
+}
+
+func TestWriter_CompareChecksum(t *testing.T) {
+	root := t.TempDir()
+	content := []byte("the same book")
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "book.epub"), content, 0o644))
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Conflict: ConflictCompare})
+
+	// The existing file has the same size.
+	_, err := c.NewWriter(1, 1, "book", "", EPUB, int64(len(content)), nil)
+	assert.ErrorIs(t, err, ErrFileExisted)
+
+	// The size is unknown, the identical file is removed after the download.
+	w, err := c.NewWriter(1, 1, "book", "", EPUB, 0, nil)
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, w.Close(), ErrFileExisted)
+	assert.Empty(t, w.Outputs())
+	_, err = os.Stat(filepath.Join(root, "book (1).epub"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWriter_OverwriteFailed(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "book.epub")
+	assert.NoError(t, os.WriteFile(path, []byte("the curated book"), 0o644))
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Conflict: ConflictOverwrite})
+
+	// The existing file is kept until the download is completed.
+	w, err := c.NewWriter(1, 1, "book", "", EPUB, 12, nil)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("the new"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Abort())
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "the curated book", string(content))
+}
+
+func TestWriter_ExtractConflict(t *testing.T) {
+	archive := zipArchive(t,
+		archiveFile{name: "book.epub", content: []byte("the new book")},
+		archiveFile{name: "same.epub", content: []byte("the same book")},
+	)
+
+	for policy, expected := range map[Conflict][]string{
+		ConflictSkip:      {"the curated book", "the same book"},
+		ConflictOverwrite: {"the new book", "the same book"},
+		ConflictRename:    {"the curated book", "the new book", "the same book", "the same book"},
+		ConflictCompare:   {"the curated book", "the new book", "the same book"},
+	} {
+		t.Run(string(policy), func(t *testing.T) {
+			root := t.TempDir()
+			assert.NoError(t, os.WriteFile(filepath.Join(root, "book.epub"), []byte("the curated book"), 0o644))
+			assert.NoError(t, os.WriteFile(filepath.Join(root, "same.epub"), []byte("the same book"), 0o644))
+			c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Extract: true, Conflict: policy})
+
+			w, err := c.NewWriter(1, 1, "archive", "", ZIP, int64(len(archive)), nil)
+			assert.NoError(t, err)
+			_, err = w.Write(archive)
+			assert.NoError(t, err)
+			assert.NoError(t, w.Close())
+
+			var contents []string
+			for _, name := range []string{"book.epub", "book (1).epub", "same.epub", "same (1).epub"} {
+				if content, err := os.ReadFile(filepath.Join(root, name)); err == nil {
+					contents = append(contents, string(content))
+				}
+			}
+			assert.Equal(t, expected, contents)
+		})
+	}
+}
@@ -3,34 +3,141 @@ package file
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"golang.org/x/text/encoding/simplifiedchinese"
+	"github.com/nwaples/rardecode/v2"
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/transform"
+
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+var (
+	ErrUnsupportedArchive = errors.New("the archive format or compression method isn't supported")
+	ErrCorruptArchive     = errors.New("the archive is corrupted")
+	ErrExtractLimit       = errors.New("the archive exceeds the extraction limits")
 )
 
-var encoding = simplifiedchinese.GB18030
+const (
+	maxArchiveDepth = 3    // The max nested level of the archives which will be extracted.
+	maxLinkLength   = 4096 // The max length of the symbolic link target.
+)
 
-// decompress - extract zip file.
+// ExtractLimits protects the extraction from the decompression bombs, zero means no limit.
+type ExtractLimits struct {
+	MaxSize  int64   // The max uncompressed bytes of an archive.
+	MaxFiles int     // The max number of the files in an archive, including the skipped files.
+	MaxRatio float64 // The max ratio of the uncompressed bytes to the archive size.
+}
+
+// extraction is the state of extracting an archive.
+type extraction struct {
+	maxSize int64 // The max uncompressed bytes by the size and ratio limits.
+	size    int64 // The uncompressed bytes.
+	files   int   // The number of the walked files.
+}
+
+// reader wraps the file content in the archive for counting the uncompressed bytes.
+func (e *extraction) reader(r io.Reader) io.Reader {
+	return &extractionReader{r: r, e: e}
+}
+
+type extractionReader struct {
+	r io.Reader
+	e *extraction
+}
+
+func (r *extractionReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.e.size += int64(n)
+	if r.e.maxSize > 0 && r.e.size > r.e.maxSize {
+		return n, fmt.Errorf("%w: more than %d uncompressed bytes", ErrExtractLimit, r.e.maxSize)
+	}
+	return n, err
+}
+
+// archiveEntry is a file in the archive.
+type archiveEntry struct {
+	name  string      // The escaped file name.
+	mode  os.FileMode // The file mode for checking the directory and symbolic link.
+	solid bool        // The content should be read for reading the next file, even if it's skipped.
+}
+
+// walkFunc will be called for every file in the archive with its content.
+type walkFunc func(entry *archiveEntry, r io.Reader) error
+
+// decompress - extract zip, rar and 7z file.
 func (p *writer) decompress() error {
-	r, err := zip.OpenReader(p.filePath())
-	if err != nil {
-		return err
+	_ = os.MkdirAll(p.download, 0o755)
+
+	e := &extraction{maxSize: p.limits.MaxSize}
+	if p.limits.MaxRatio > 0 {
+		ratio := int64(float64(p.offset) * p.limits.MaxRatio)
+		if e.maxSize == 0 || ratio < e.maxSize {
+			e.maxSize = ratio
+		}
 	}
-	defer func() {
-		if err := r.Close(); err != nil {
-			panic(err)
+
+	return p.extractArchive(e, p.filePath(), p.format, p.download, 0)
+}
+
+// extractArchive will extract the archive into the given directory, the nested archives are extracted recursively.
+func (p *writer) extractArchive(e *extraction, path string, format Format, dir string, depth int) error {
+	walk := func(entry *archiveEntry, r io.Reader) error {
+		if e.files++; p.limits.MaxFiles > 0 && e.files > p.limits.MaxFiles {
+			return fmt.Errorf("%w: more than %d files", ErrExtractLimit, p.limits.MaxFiles)
 		}
-	}()
 
-	_ = os.MkdirAll(p.download, 0o755)
+		r = e.reader(r)
+		if err := p.extractEntry(e, entry, r, dir, depth); err != nil {
+			return err
+		}
+		if entry.solid {
+			_, err := io.Copy(io.Discard, r)
+			return err
+		}
+		return nil
+	}
+
+	switch format {
+	case ZIP:
+		return walkZip(path, p.encoding, walk)
+	case RAR:
+		return walkRar(path, walk)
+	case SevenZip:
+		return walkSevenZip(path, walk)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedArchive, format)
+	}
+}
+
+// walkZip will decode the file names by the given encoding, or detect it if it's nil.
+func walkZip(path string, e encoding.Encoding, walk walkFunc) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorruptArchive, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	if e == nil {
+		var names []string
+		for _, f := range r.File {
+			if f.Flags&zipUTF8Flag == 0 {
+				names = append(names, f.Name)
+			}
+		}
+		e = detectEncoding(names)
+	}
 
 	for _, f := range r.File {
-		if err := p.extractAndWriteFile(f); err != nil {
+		if err := walkZipFile(f, e, walk); err != nil {
 			return err
 		}
 	}
@@ -39,72 +146,166 @@ func (p *writer) decompress() error {
 }
 
 // Closure to address file descriptors issue with all the deferred Close() methods.
-func (p *writer) extractAndWriteFile(f *zip.File) error {
+func walkZipFile(f *zip.File, e encoding.Encoding, walk walkFunc) error {
 	rc, err := f.Open()
 	if err != nil {
-		return err
+		if errors.Is(err, zip.ErrAlgorithm) {
+			return fmt.Errorf("%w: %w", ErrUnsupportedArchive, err)
+		}
+		return fmt.Errorf("%w: %w", ErrCorruptArchive, err)
 	}
-	defer func() {
-		if err := rc.Close(); err != nil {
-			panic(err)
+	defer func() { _ = rc.Close() }()
+
+	name := escape(f.Name)
+	if f.Flags&zipUTF8Flag == 0 {
+		name = encodingFilename(f.Name, e)
+	}
+
+	return walk(&archiveEntry{name: name, mode: f.Mode()}, rc)
+}
+
+func walkRar(path string, walk walkFunc) error {
+	r, err := rardecode.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorruptArchive, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return nil
 		}
-	}()
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrCorruptArchive, err)
+		}
+		// The RAR 5 stores the link target in the header which isn't provided by the decoder.
+		if h.Mode()&os.ModeSymlink != 0 {
+			log.Warnf("Skip the symbolic link %s in the RAR archive.", h.Name)
+			continue
+		}
+		if err := walk(&archiveEntry{name: escape(h.Name), mode: h.Mode()}, r); err != nil {
+			return err
+		}
+	}
+}
 
-	filename := encodingFilename(f.Name)
-	ext, ok := Extension(filename)
-	if !ok || !p.formats[ext] {
+func (p *writer) extractEntry(e *extraction, entry *archiveEntry, r io.Reader, dir string, depth int) error {
+	ext, ok := Extension(entry.name)
+	nested := ok && ext.Archive() && depth < maxArchiveDepth && !entry.mode.IsDir()
+	if !ok || !p.formats[ext] && !nested {
 		// No need to extract this file. Skip.
 		return nil
 	}
 
-	path, err := sanitizeArchivePath(p.download, filename)
+	path, err := sanitizeArchivePath(dir, entry.name)
 	if err != nil {
 		return err
 	}
 
-	if !strings.HasPrefix(path, filepath.Clean(p.download)+string(os.PathSeparator)) {
+	if !isSubPath(dir, path) {
 		return fmt.Errorf("%s: illegal file path", path)
 	}
 
-	if f.FileInfo().IsDir() {
+	switch {
+	case entry.mode.IsDir():
 		_ = os.MkdirAll(path, 0o755)
-	} else {
-		mode := f.FileHeader.Mode()
-		if mode&os.ModeType == os.ModeSymlink {
-			data, err := io.ReadAll(rc)
-			if err != nil {
-				return err
-			}
-			_ = writeSymbolicLink(path, string(data))
-		} else {
-			_ = os.MkdirAll(filepath.Dir(path), 0o755)
+	case entry.mode&os.ModeType == os.ModeSymlink:
+		data, err := io.ReadAll(io.LimitReader(r, maxLinkLength))
+		if err != nil {
+			return err
+		}
+		// The link shouldn't point to the files outside the download directory.
+		target := string(data)
+		if filepath.IsAbs(target) || !isSubPath(p.download, filepath.Join(filepath.Dir(path), target)) {
+			log.Warnf("Skip the symbolic link %s in the archive, its target %s is outside the download directory.", entry.name, target)
+			return nil
+		}
+		if path, _ = p.resolveEntry(path); path == "" {
+			return nil
+		}
+		_ = os.Remove(path)
+		_ = writeSymbolicLink(path, target)
+	case nested:
+		// The nested archive is extracted into the same directory and removed like the downloaded archive.
+		path = UniquePath(path)
+		if _, err := writeEntry(path, r); err != nil {
+			return err
+		}
+		defer func() { _ = os.Remove(path) }()
+		return p.extractArchive(e, path, ext, filepath.Dir(path), depth+1)
+	default:
+		path, existing := p.resolveEntry(path)
+		if path == "" {
+			return nil
+		}
+		output, err := writeEntry(path, r)
+		if err != nil {
+			return err
+		}
+		if checksum, _ := hex.DecodeString(output.SHA256); existing != "" && sameFile(existing, checksum) {
+			log.Infof("Skip the extracted file %s, it's identical to %s", path, existing)
 			_ = os.Remove(path)
-			outFile, err := os.Create(path)
-			if err != nil {
-				return err
-			}
-			defer func() {
-				if err := outFile.Close(); err != nil {
-					panic(err)
-				}
-			}()
-
-			// G110: Potential DoS vulnerability via decompression bomb.
-			for {
-				_, err := io.CopyN(outFile, rc, bytes.MinRead)
-				if err != nil {
-					if err == io.EOF {
-						break
-					}
-					return err
-				}
-			}
+			return nil
 		}
+		p.outputs = append(p.outputs, *output)
 	}
 
 	return nil
 }
 
+// resolveEntry chooses the path of the extracted file by the conflict policy like the downloaded file, the empty path
+// means the file should be skipped. The existing path is returned if the extracted file should be compared with it.
+func (p *writer) resolveEntry(path string) (string, string) {
+	resolved, existing, err := p.conflict.resolve(path, 0)
+	if err != nil {
+		log.Infof("Skip the extracted file %s, it has been existed.", path)
+		return "", ""
+	}
+	return resolved, existing
+}
+
+// writeEntry will save the file content from the archive. The content is written into a partial file which replaces
+// the file of the given path after it's completed.
+func writeEntry(path string, r io.Reader) (output *Output, err error) {
+	_ = os.MkdirAll(filepath.Dir(path), 0o755)
+	outFile, err := os.Create(path + partSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := outFile.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(path+partSuffix, path)
+		}
+		// Remove the partial file.
+		if err != nil {
+			_ = os.Remove(path + partSuffix)
+		}
+	}()
+
+	// Calculate the checksum for the extracted file.
+	digest := sha256.New()
+	out := io.MultiWriter(outFile, digest)
+	size := int64(0)
+
+	// G110: Potential DoS vulnerability via decompression bomb.
+	for {
+		n, err := io.CopyN(out, r, bytes.MinRead)
+		size += n
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+	}
+
+	return &Output{Path: path, Size: size, SHA256: hex.EncodeToString(digest.Sum(nil))}, nil
+}
+
 func writeSymbolicLink(filePath string, targetPath string) error {
 	err := os.MkdirAll(filepath.Dir(filePath), 0o755)
 	if err != nil {
@@ -119,6 +320,11 @@ func writeSymbolicLink(filePath string, targetPath string) error {
 	return nil
 }
 
+// isSubPath checks if the path is inside the directory.
+func isSubPath(dir, path string) bool {
+	return strings.HasPrefix(filepath.Clean(path), filepath.Clean(dir)+string(os.PathSeparator))
+}
+
 // sanitizeArchivePath sanitize archive file pathing from "G305: Zip Slip vulnerability"
 func sanitizeArchivePath(d, t string) (v string, err error) {
 	v = filepath.Join(d, t)
@@ -129,10 +335,13 @@ func sanitizeArchivePath(d, t string) (v string, err error) {
 	return "", fmt.Errorf("%s: %s", "content filepath is tainted", t)
 }
 
-// encodingFilename will convert the GBK into UTF-8 and escape invalid characters.
-func encodingFilename(name string) string {
+// encodingFilename will convert the name into UTF-8 and escape invalid characters, GB18030 is used if encoding is nil.
+func encodingFilename(name string, e encoding.Encoding) string {
+	if e == nil {
+		e = defaultEncoding
+	}
 	i := bytes.NewReader([]byte(name))
-	decoder := transform.NewReader(i, encoding.NewDecoder())
+	decoder := transform.NewReader(i, e.NewDecoder())
 	content, err := io.ReadAll(decoder)
 	if err != nil {
 		// Fallback to default UTF-8 encoding
@@ -0,0 +1,213 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type archiveFile struct {
+	name    string
+	content []byte
+}
+
+func zipArchive(t *testing.T, files ...archiveFile) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		assert.NoError(t, err)
+		_, err = fw.Write(f.content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func extractArchive(t *testing.T, root string, format Format, content []byte) []Output {
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB, MOBI}, Extract: true})
+	w, err := c.NewWriter(1, 1, "archive", "", format, int64(len(content)), nil)
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	// The archive should be removed after the extraction.
+	_, err = os.Stat(filepath.Join(root, "archive."+string(format)))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	return w.Outputs()
+}
+
+// walkTestdata reads the content of every file in the archive under testdata.
+func walkTestdata(t *testing.T, name string) (map[string][]byte, error) {
+	walker := walkRar
+	if ext, _ := Extension(name); ext == SevenZip {
+		walker = walkSevenZip
+	}
+
+	files := map[string][]byte{}
+	err := walker(filepath.Join("testdata", name), func(entry *archiveEntry, r io.Reader) error {
+		content, err := io.ReadAll(r)
+		if err == nil && !entry.mode.IsDir() {
+			files[entry.name] = content
+		}
+		return err
+	})
+	return files, err
+}
+
+func TestWalkSevenZip(t *testing.T) {
+	expected, err := walkTestdata(t, "copy.7z")
+	assert.NoError(t, err)
+	assert.Len(t, expected, 10)
+
+	// The same files are compressed by the different coders and filters of 7-Zip, the solid archives are included.
+	for _, name := range []string{"lzma.7z", "lzma2.7z", "bcj2.7z"} {
+		t.Run(name, func(t *testing.T) {
+			files, err := walkTestdata(t, name)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, files)
+		})
+	}
+
+	files, err := walkTestdata(t, "bcj.7z")
+	assert.NoError(t, err)
+	assert.Len(t, files["bcj"], 8549)
+
+	// The directories and the empty files.
+	files, err = walkTestdata(t, "empty.7z")
+	assert.NoError(t, err)
+	assert.Len(t, files, 5)
+}
+
+func TestWalkSevenZip_Unsupported(t *testing.T) {
+	_, err := walkTestdata(t, "aes7z.7z")
+	assert.ErrorIs(t, err, ErrUnsupportedArchive)
+}
+
+func TestWalkSevenZip_Checksum(t *testing.T) {
+	archive, err := os.ReadFile(filepath.Join("testdata", "copy.7z"))
+	assert.NoError(t, err)
+	// Modify the content of the first file which is stored without compression after the signature header.
+	archive[32] ^= 0xff
+
+	path := filepath.Join(t.TempDir(), "copy.7z")
+	assert.NoError(t, os.WriteFile(path, archive, 0o644))
+	err = walkSevenZip(path, func(entry *archiveEntry, r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	})
+	assert.ErrorIs(t, err, ErrCorruptArchive)
+}
+
+func TestWalkRar(t *testing.T) {
+	files, err := walkTestdata(t, "rar4.rar")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"rar": []byte("unarr\n")}, files)
+
+	// The symbolic links are skipped.
+	files, err = walkTestdata(t, "rar5.rar")
+	assert.NoError(t, err)
+	assert.Len(t, files, 5)
+	assert.Len(t, files[escape("testdata/already-compressed.jpg")], 8944)
+}
+
+func TestWriter_ExtractSevenZipLimits(t *testing.T) {
+	archive, err := os.ReadFile(filepath.Join("testdata", "lzma2.7z"))
+	assert.NoError(t, err)
+
+	// No file is extracted, but the skipped files in the solid archive are still decompressed and counted.
+	root := t.TempDir()
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Extract: true, Limits: ExtractLimits{MaxRatio: 2}})
+	w, err := c.NewWriter(1, 1, "archive", "", SevenZip, int64(len(archive)), nil)
+	assert.NoError(t, err)
+	_, err = w.Write(archive)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, w.Close(), ErrExtractLimit)
+}
+
+func TestWriter_ExtractNestedArchives(t *testing.T) {
+	nested, err := os.ReadFile(filepath.Join("testdata", "rar5.rar"))
+	assert.NoError(t, err)
+	archive := zipArchive(t,
+		archiveFile{name: "book.txt", content: []byte("the txt book")},
+		archiveFile{name: "nested.rar", content: nested},
+	)
+
+	root := t.TempDir()
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{TXT}, Extract: true})
+	w, err := c.NewWriter(1, 1, "archive", "", ZIP, int64(len(archive)), nil)
+	assert.NoError(t, err)
+	_, err = w.Write(archive)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	// The text files in the nested RAR archive are extracted, the image and the symbolic links are skipped.
+	assert.Len(t, w.Outputs(), 5)
+	content, err := os.ReadFile(filepath.Join(root, escape("testdata/proverbs/extra/proverb3.txt")))
+	assert.NoError(t, err)
+	assert.Len(t, content, 39)
+	for _, name := range []string{"nested.rar", escape("testdata/already-compressed.jpg"), escape("testdata/proverb3.txt")} {
+		_, err = os.Stat(filepath.Join(root, name))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
+
+func TestWriter_ExtractLimits(t *testing.T) {
+	archive := zipArchive(t,
+		archiveFile{name: "first.epub", content: bytes.Repeat([]byte("a"), 4096)},
+		archiveFile{name: "second.epub", content: bytes.Repeat([]byte("b"), 4096)},
+	)
+
+	for name, limits := range map[string]ExtractLimits{
+		"size":  {MaxSize: 6000},
+		"files": {MaxFiles: 1},
+		"ratio": {MaxRatio: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Extract: true, Limits: limits})
+			w, err := c.NewWriter(1, 1, "archive", "", ZIP, int64(len(archive)), nil)
+			assert.NoError(t, err)
+			_, err = w.Write(archive)
+			assert.NoError(t, err)
+			assert.ErrorIs(t, w.Close(), ErrExtractLimit)
+			assert.Empty(t, w.Outputs())
+
+			// The extracted files are removed and the archive is kept.
+			_, err = os.Stat(filepath.Join(root, "first.epub"))
+			assert.ErrorIs(t, err, os.ErrNotExist)
+			_, err = os.Stat(filepath.Join(root, "archive.zip"))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWriter_ExtractSymbolicLinks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for name, target := range map[string]string{"inside.epub": "book.epub", "outside.epub": "../../etc/passwd"} {
+		header := &zip.FileHeader{Name: name}
+		header.SetMode(os.ModeSymlink | 0o777)
+		fw, err := w.CreateHeader(header)
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(target))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	root := t.TempDir()
+	extractArchive(t, root, ZIP, buf.Bytes())
+
+	target, err := os.Readlink(filepath.Join(root, "inside.epub"))
+	assert.NoError(t, err)
+	assert.Equal(t, "book.epub", target)
+	_, err = os.Lstat(filepath.Join(root, "outside.epub"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,212 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+type Dedupe string // The policy for handling the duplicated files.
+
+const (
+	DedupeKeep Dedupe = "keep" // Keep the duplicated files.
+	DedupeSkip Dedupe = "skip" // Remove the downloaded file if an identical file exists.
+	DedupeLink Dedupe = "link" // Replace the downloaded file with a hard link to the identical file.
+)
+
+// ParseDedupe will create the dedupe policy from the string.
+func ParseDedupe(policy string) (Dedupe, error) {
+	switch d := Dedupe(strings.ToLower(policy)); d {
+	case DedupeKeep, DedupeSkip, DedupeLink:
+		return d, nil
+	case "":
+		return DedupeKeep, nil
+	default:
+		return "", fmt.Errorf("invalid dedupe policy %s, it should be one of keep, skip or link", policy)
+	}
+}
+
+type indexEntry struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Path   string `json:"path"`
+}
+
+// Index is a content-addressed index for all the downloaded files from all the sources.
+type Index struct {
+	path    string
+	entries map[string]indexEntry
+	lock    sync.Mutex
+}
+
+// OpenIndex will load the index file. A new index will be created if the file doesn't exist.
+func OpenIndex(path string) (*Index, error) {
+	index := &Index{path: path, entries: map[string]indexEntry{}}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return index, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry indexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Debugf("Skip the invalid index entry: %s", scanner.Text())
+			continue
+		}
+		index.entries[entry.SHA256] = entry
+	}
+
+	return index, scanner.Err()
+}
+
+// Lookup will return the existed file path which has the same content.
+func (i *Index) Lookup(output *Output) (string, bool) {
+	i.lock.Lock()
+	entry, ok := i.entries[output.SHA256]
+	i.lock.Unlock()
+
+	if path, err := filepath.Abs(output.Path); !ok || err != nil || entry.Path == path {
+		return "", false
+	}
+
+	// The indexed file may be removed or modified by the user.
+	if stat, err := os.Stat(entry.Path); err != nil || stat.Size() != entry.Size {
+		return "", false
+	}
+
+	return entry.Path, true
+}
+
+// Add will save the given file into the index.
+func (i *Index) Add(output *Output) error {
+	path, err := filepath.Abs(output.Path)
+	if err != nil {
+		return err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.save(indexEntry{SHA256: output.SHA256, Size: output.Size, Path: path})
+}
+
+// Move will update the path of the indexed file which has been moved, such as by the move hook.
+func (i *Index) Move(source, target string) error {
+	source, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, entry := range i.entries {
+		if entry.Path == source {
+			entry.Path = target
+			if err := i.save(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// save will append the entry into the index file, the last entry of the same checksum is used in loading the index.
+func (i *Index) save(entry indexEntry) error {
+	line, err := json.Marshal(&entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(i.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	i.entries[entry.SHA256] = entry
+
+	return nil
+}
+
+// deduplicate will handle the duplicated outputs by the dedupe policy. The skipped outputs will be removed.
+// The outputs which aren't duplicated are returned for indexing them after embedding the metadata.
+func (p *writer) deduplicate(outputs []Output) (res, fresh []Output) {
+	if p.index == nil {
+		return outputs, nil
+	}
+
+	for i := range outputs {
+		output := outputs[i]
+		existed, ok := p.index.Lookup(&output)
+		if !ok {
+			res = append(res, output)
+			fresh = append(fresh, output)
+			continue
+		}
+
+		switch p.dedupe {
+		case DedupeSkip:
+			log.Infof("Skip the duplicated file %s, it's identical to %s", output.Path, existed)
+			_ = os.Remove(output.Path)
+			continue
+		case DedupeLink:
+			if err := replaceWithLink(existed, output.Path); err != nil {
+				log.Warnf("Failed to link the duplicated file %s to %s: %v", output.Path, existed, err)
+			} else {
+				p.linked = append(p.linked, output.Path)
+			}
+		default:
+			log.Debugf("Keep the duplicated file %s, it's identical to %s", output.Path, existed)
+		}
+		res = append(res, output)
+	}
+
+	return res, fresh
+}
+
+// indexOutputs will save the downloaded digest with the final size of the files, which may be changed by embedding
+// the metadata. So the same book from the other sources is still found as a duplicate.
+func (p *writer) indexOutputs(fresh []Output) {
+	for i := range fresh {
+		output := fresh[i]
+		if stat, err := os.Stat(output.Path); err == nil {
+			output.Size = stat.Size()
+		}
+		if err := p.index.Add(&output); err != nil {
+			log.Warnf("Failed to index the file %s: %v", output.Path, err)
+		}
+	}
+}
+
+// replaceWithLink will replace the path with a hard link to the target file atomically.
+func replaceWithLink(target, path string) error {
+	temp := path + ".link"
+	_ = os.Remove(temp)
+	if err := os.Link(target, temp); err != nil {
+		return err
+	}
+
+	return os.Rename(temp, path)
+}
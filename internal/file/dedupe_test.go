@@ -0,0 +1,99 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeBook(t *testing.T, c Creator, id int64, name string, content []byte) []Output {
+	w, err := c.NewWriter(id, 2, name, "", EPUB, int64(len(content)), nil)
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return w.Outputs()
+}
+
+func TestWriter_Deduplicate(t *testing.T) {
+	content := []byte("the same book from different sources")
+
+	for _, policy := range []Dedupe{DedupeKeep, DedupeSkip, DedupeLink} {
+		t.Run(string(policy), func(t *testing.T) {
+			root := t.TempDir()
+			index, err := OpenIndex(filepath.Join(root, "content.index"))
+			assert.NoError(t, err)
+			c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Dedupe: policy, Index: index})
+
+			assert.Len(t, writeBook(t, c, 1, "first", content), 1)
+			outputs := writeBook(t, c, 2, "second", content)
+
+			_, err = os.Stat(filepath.Join(root, "second.epub"))
+			if policy == DedupeSkip {
+				assert.Empty(t, outputs)
+				assert.ErrorIs(t, err, os.ErrNotExist)
+				return
+			}
+			assert.Len(t, outputs, 1)
+			assert.NoError(t, err)
+
+			first, _ := os.Stat(filepath.Join(root, "first.epub"))
+			second, _ := os.Stat(filepath.Join(root, "second.epub"))
+			assert.Equal(t, policy == DedupeLink, os.SameFile(first, second))
+		})
+	}
+}
+
+func TestWriter_DeduplicateEmbedded(t *testing.T) {
+	content := epubBook(t, testOPF)
+
+	for _, policy := range []Dedupe{DedupeSkip, DedupeLink} {
+		t.Run(string(policy), func(t *testing.T) {
+			root := t.TempDir()
+			index, err := OpenIndex(filepath.Join(root, "content.index"))
+			assert.NoError(t, err)
+			c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Dedupe: policy, Index: index, Embed: true})
+
+			// The same book from the different sources has different metadata embedded.
+			for i, source := range []string{"talebook", "sobooks"} {
+				w, err := c.NewWriter(int64(i+1), 2, source, "", EPUB, int64(len(content)), &Metadata{Title: "Pride and Prejudice", Source: source, ID: int64(i + 1)})
+				assert.NoError(t, err)
+				_, err = w.Write(content)
+				assert.NoError(t, err)
+				assert.NoError(t, w.Close())
+			}
+
+			_, err = os.Stat(filepath.Join(root, "sobooks.epub"))
+			if policy == DedupeSkip {
+				assert.ErrorIs(t, err, os.ErrNotExist)
+				return
+			}
+			first, _ := os.Stat(filepath.Join(root, "talebook.epub"))
+			second, _ := os.Stat(filepath.Join(root, "sobooks.epub"))
+			assert.True(t, os.SameFile(first, second))
+			assert.Contains(t, readEPUB(t, filepath.Join(root, "sobooks.epub"))["OEBPS/content.opf"], "talebook:1")
+		})
+	}
+}
+
+func TestIndex_Move(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "content.index")
+	index, err := OpenIndex(path)
+	assert.NoError(t, err)
+
+	source, target := filepath.Join(root, "book.epub"), filepath.Join(root, "library", "book.epub")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(target), 0o755))
+	assert.NoError(t, os.WriteFile(target, []byte("the book"), 0o644))
+	assert.NoError(t, index.Add(&Output{Path: source, Size: 8, SHA256: "checksum"}))
+	assert.NoError(t, index.Move(source, target))
+
+	// The moved path is saved into the index file.
+	index, err = OpenIndex(path)
+	assert.NoError(t, err)
+	existed, ok := index.Lookup(&Output{Path: filepath.Join(root, "copy.epub"), Size: 8, SHA256: "checksum"})
+	assert.True(t, ok)
+	assert.Equal(t, target, existed)
+}
@@ -0,0 +1,296 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+const (
+	dcNamespace  = "http://purl.org/dc/elements/1.1/"
+	epubCoverID  = "bookhunter-cover"
+	maxOPFLength = 4 << 20
+)
+
+var (
+	ErrInvalidEPUB = errors.New("invalid epub file")
+
+	rootFileRe    = regexp.MustCompile(`<(?:\w+:)?rootfile\b[^>]*\bfull-path="([^"]+)"`)
+	dcPrefixRe    = regexp.MustCompile(`xmlns:(\w+)="` + regexp.QuoteMeta(dcNamespace) + `"`)
+	metadataEndRe = regexp.MustCompile(`</(?:\w+:)?metadata>`)
+	manifestEndRe = regexp.MustCompile(`</(?:\w+:)?manifest>`)
+	coverMetaRe   = regexp.MustCompile(`<(?:\w+:)?meta\b[^>]*\bname="cover"[^>]*?(?:/>|>\s*</(?:\w+:)?meta>)\s*`)
+	coverItemRe   = regexp.MustCompile(`<(?:\w+:)?item\b[^>]*\bid="` + epubCoverID + `"[^>]*?(?:/>|>\s*</(?:\w+:)?item>)\s*`)
+	versionRe     = regexp.MustCompile(`<(?:\w+:)?package\b[^>]*\bversion="(\d)`)
+	coverTypes    = map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif", "image/webp": ".webp"}
+)
+
+// dcElementRe matches the Dublin Core elements with the given name, such as <dc:title>.
+func dcElementRe(prefix, name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)<` + prefix + `:` + name + `\b[^>]*?(?:/>|>.*?</` + prefix + `:` + name + `>)\s*`)
+}
+
+// embedMetadata will rewrite the OPF file in the EPUB by the metadata. The EPUB is replaced only if the rewriting
+// succeeded, the original file is kept on any errors.
+func embedMetadata(path string, m *Metadata) (err error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEPUB, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	files := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+
+	// Find the OPF file from the container.
+	container, err := readZipFile(files["META-INF/container.xml"])
+	if err != nil {
+		return err
+	}
+	match := rootFileRe.FindSubmatch(container)
+	if match == nil {
+		return fmt.Errorf("%w: no rootfile in the container", ErrInvalidEPUB)
+	}
+	opfPath := html.UnescapeString(string(match[1]))
+	opf, err := readZipFile(files[opfPath])
+	if err != nil {
+		return err
+	}
+
+	// The cover is added as a new image in the same directory with the OPF file.
+	var cover *zip.FileHeader
+	coverType := http.DetectContentType(m.CoverImage)
+	if ext, ok := coverTypes[coverType]; ok && len(m.CoverImage) > 0 {
+		cover = &zip.FileHeader{Name: opfRelative(opfPath, epubCoverID+ext), Method: zip.Store}
+	}
+
+	content, err := rewriteOPF(string(opf), m, cover, coverType)
+	if err != nil {
+		return err
+	}
+
+	// Write the new EPUB into a temporary file, and replace the original file at last.
+	temp, err := os.CreateTemp(filepath.Dir(path), ".embed-*.epub")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = temp.Close()
+			_ = os.Remove(temp.Name())
+		}
+	}()
+
+	w := zip.NewWriter(temp)
+	for _, f := range r.File {
+		if cover != nil && f.Name == cover.Name {
+			continue
+		}
+		if f.Name != opfPath {
+			// The mimetype file is kept as the first stored file.
+			if err := w.Copy(f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: f.Name, Method: f.Method, Modified: f.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, content); err != nil {
+			return err
+		}
+	}
+	if cover != nil {
+		fw, err := w.CreateHeader(cover)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(m.CoverImage); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	_ = r.Close()
+
+	return os.Rename(temp.Name(), path)
+}
+
+// opfRelative will join the file name with the directory of the OPF file in the EPUB.
+func opfRelative(opfPath, name string) string {
+	if dir := path.Dir(opfPath); dir != "." {
+		return dir + "/" + name
+	}
+	return name
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("%w: missing the required file", ErrInvalidEPUB)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxOPFLength+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxOPFLength {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidEPUB, f.Name)
+	}
+	return content, nil
+}
+
+// rewriteOPF replaces the title, creators and publisher, and appends the identifiers and cover in the OPF content.
+// The other elements are kept as is.
+func rewriteOPF(opf string, m *Metadata, cover *zip.FileHeader, coverType string) (string, error) {
+	end := metadataEndRe.FindStringIndex(opf)
+	if end == nil {
+		return "", fmt.Errorf("%w: no metadata in the opf", ErrInvalidEPUB)
+	}
+
+	// Use the Dublin Core prefix in the OPF, or declare it in every added element.
+	prefix, xmlns := "dc", ` xmlns:dc="`+dcNamespace+`"`
+	if match := dcPrefixRe.FindStringSubmatch(opf); match != nil {
+		prefix, xmlns = match[1], ""
+	}
+	element := func(name, value string) string {
+		return fmt.Sprintf("<%s:%s%s>%s</%s:%s>\n", prefix, name, xmlns, escapeXML(value), prefix, name)
+	}
+
+	metadata, rest := opf[:end[0]], opf[end[0]:]
+	var added strings.Builder
+	if m.Title != "" {
+		metadata = dcElementRe(prefix, "title").ReplaceAllString(metadata, "")
+		added.WriteString(element("title", m.Title))
+	}
+	if len(m.Authors) > 0 {
+		metadata = dcElementRe(prefix, "creator").ReplaceAllString(metadata, "")
+		for _, author := range m.Authors {
+			added.WriteString(element("creator", author))
+		}
+	}
+	if m.Publisher != "" {
+		metadata = dcElementRe(prefix, "publisher").ReplaceAllString(metadata, "")
+		added.WriteString(element("publisher", m.Publisher))
+	}
+
+	// The existing identifiers are kept, the unique-identifier of the package may refer to them.
+	var identifiers []string
+	if m.Source != "" && m.ID > 0 {
+		identifiers = append(identifiers, m.Source+":"+strconv.FormatInt(m.ID, 10))
+	}
+	if m.ISBN != "" {
+		identifiers = append(identifiers, "urn:isbn:"+m.ISBN)
+	}
+	for _, identifier := range identifiers {
+		if !strings.Contains(metadata, ">"+escapeXML(identifier)+"<") {
+			added.WriteString(element("identifier", identifier))
+		}
+	}
+
+	if cover != nil {
+		metadata = coverMetaRe.ReplaceAllString(metadata, "")
+		added.WriteString(`<meta name="cover" content="` + epubCoverID + `"/>` + "\n")
+
+		// The EPUB 3 uses the properties to define the cover image.
+		item := `<item id="%s" href="%s" media-type="%s"/>` + "\n"
+		if match := versionRe.FindStringSubmatch(opf); match != nil && match[1] >= "3" {
+			item = `<item id="%s" href="%s" media-type="%s" properties="cover-image"/>` + "\n"
+		}
+		// Remove the cover which was embedded before.
+		rest = coverItemRe.ReplaceAllString(rest, "")
+		loc := manifestEndRe.FindStringIndex(rest)
+		if loc == nil {
+			return "", fmt.Errorf("%w: no manifest in the opf", ErrInvalidEPUB)
+		}
+		rest = rest[:loc[0]] + fmt.Sprintf(item, epubCoverID, path.Base(cover.Name), coverType) + rest[loc[0]:]
+	}
+
+	content := metadata + added.String() + rest
+
+	// Validate the rewritten OPF for avoiding breaking the EPUB.
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return content, nil
+			}
+			return "", fmt.Errorf("%w: the rewritten opf is broken: %v", ErrInvalidEPUB, err)
+		}
+	}
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// embedMetadata will embed the metadata into the EPUB output. The archive may contain the different books, so the
+// metadata is only embedded when there is only one EPUB file.
+func (p *writer) embedMetadata() {
+	index := -1
+	for i, output := range p.outputs {
+		if strings.EqualFold(filepath.Ext(output.Path), "."+string(EPUB)) {
+			if index >= 0 {
+				return
+			}
+			index = i
+		}
+	}
+	if index < 0 {
+		return
+	}
+
+	output := &p.outputs[index]
+	if stat, err := os.Lstat(output.Path); err != nil || !stat.Mode().IsRegular() {
+		return
+	}
+	// The hard link shares the content with the identical file, it shouldn't be rewritten.
+	if slices.Contains(p.linked, output.Path) {
+		return
+	}
+	if err := embedMetadata(output.Path, p.metadata); err != nil {
+		log.Warnf("Failed to embed the metadata into %s: %v", output.Path, err)
+		return
+	}
+
+	// Update the size and checksum of the rewritten file.
+	digest := sha256.New()
+	if err := hashFile(digest, output.Path); err != nil {
+		log.Warnf("Failed to calculate the checksum of %s: %v", output.Path, err)
+		return
+	}
+	if stat, err := os.Stat(output.Path); err == nil {
+		output.Size = stat.Size()
+	}
+	output.SHA256 = hex.EncodeToString(digest.Sum(nil))
+}
@@ -0,0 +1,136 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testOPF = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="uid" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="uid">urn:uuid:0a1b2c3d</dc:identifier>
+    <dc:title>www.junk-site.com 01</dc:title>
+    <dc:creator>Unknown</dc:creator>
+    <dc:language>en</dc:language>
+    <meta name="cover" content="old-cover"/>
+  </metadata>
+  <manifest>
+    <item id="old-cover" href="old.jpg" media-type="image/jpeg"/>
+    <item id="text" href="text.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="text"/></spine>
+</package>`
+
+func epubBook(t *testing.T, opf string) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, f := range []archiveFile{
+		{name: "mimetype", content: []byte("application/epub+zip")},
+		{name: "META-INF/container.xml", content: []byte(`<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`)},
+		{name: "OEBPS/content.opf", content: []byte(opf)},
+		{name: "OEBPS/text.xhtml", content: []byte("<html><body>the book</body></html>")},
+	} {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Store})
+		assert.NoError(t, err)
+		_, err = fw.Write(f.content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func readEPUB(t *testing.T, path string) map[string]string {
+	r, err := zip.OpenReader(path)
+	assert.NoError(t, err)
+	defer func() { _ = r.Close() }()
+
+	files := map[string]string{}
+	for i, f := range r.File {
+		content, err := readZipFile(f)
+		assert.NoError(t, err)
+		files[f.Name] = string(content)
+		if i == 0 {
+			assert.Equal(t, "mimetype", f.Name)
+		}
+	}
+	return files
+}
+
+func TestWriter_EmbedMetadata(t *testing.T) {
+	root := t.TempDir()
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Embed: true})
+	metadata := &Metadata{
+		Title:      "Pride & Prejudice",
+		Authors:    []string{"Jane Austen"},
+		Publisher:  "T. Egerton",
+		Source:     "talebook",
+		ID:         42,
+		ISBN:       "9780141439518",
+		CoverImage: []byte("\x89PNG\r\n\x1a\nthe cover image"),
+	}
+
+	content := epubBook(t, testOPF)
+	w, err := c.NewWriter(42, 1, "book", "", EPUB, int64(len(content)), metadata)
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	output := w.Outputs()[0]
+	stat, err := os.Stat(output.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, stat.Size(), output.Size)
+
+	files := readEPUB(t, output.Path)
+	assert.Equal(t, "\x89PNG\r\n\x1a\nthe cover image", files["OEBPS/bookhunter-cover.png"])
+	opf := files["OEBPS/content.opf"]
+	for _, expected := range []string{
+		`<dc:identifier id="uid">urn:uuid:0a1b2c3d</dc:identifier>`,
+		`<dc:title>Pride &amp; Prejudice</dc:title>`,
+		`<dc:creator>Jane Austen</dc:creator>`,
+		`<dc:publisher>T. Egerton</dc:publisher>`,
+		`<dc:identifier>talebook:42</dc:identifier>`,
+		`<dc:identifier>urn:isbn:9780141439518</dc:identifier>`,
+		`<dc:language>en</dc:language>`,
+		`<meta name="cover" content="bookhunter-cover"/>`,
+		`<item id="bookhunter-cover" href="bookhunter-cover.png" media-type="image/png" properties="cover-image"/>`,
+	} {
+		assert.Contains(t, opf, expected)
+	}
+	for _, removed := range []string{"junk-site", "Unknown", `content="old-cover"`} {
+		assert.NotContains(t, opf, removed)
+	}
+
+	// Embed the metadata again shouldn't duplicate the cover.
+	assert.NoError(t, embedMetadata(output.Path, metadata))
+	assert.Equal(t, 1, strings.Count(readEPUB(t, output.Path)["OEBPS/content.opf"], `id="bookhunter-cover"`))
+}
+
+func TestWriter_EmbedMetadataFailed(t *testing.T) {
+	root := t.TempDir()
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, Embed: true})
+
+	// The broken OPF is kept as is.
+	content := epubBook(t, "<package><manifest></manifest></package>")
+	w, err := c.NewWriter(1, 1, "broken", "", EPUB, int64(len(content)), &Metadata{Title: "Emma"})
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	saved, err := os.ReadFile(filepath.Join(root, "broken.epub"))
+	assert.NoError(t, err)
+	assert.Equal(t, content, saved)
+	entries, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -9,26 +9,38 @@ import (
 type Format string // The supported file extension.
 
 const (
-	EPUB Format = "epub"
-	MOBI Format = "mobi"
-	AZW  Format = "azw"
-	AZW3 Format = "azw3"
-	PDF  Format = "pdf"
-	ZIP  Format = "zip"
+	EPUB     Format = "epub"
+	MOBI     Format = "mobi"
+	AZW      Format = "azw"
+	AZW3     Format = "azw3"
+	PDF      Format = "pdf"
+	ZIP      Format = "zip"
+	TXT      Format = "txt"
+	DJVU     Format = "djvu"
+	FB2      Format = "fb2"
+	CBZ      Format = "cbz"
+	CBR      Format = "cbr"
+	DOCX     Format = "docx"
+	RAR      Format = "rar"
+	SevenZip Format = "7z"
 )
 
-// The Archive will return if this format is an archive.
+// The Archive will return if this format is an archive which could be extracted.
 func (f Format) Archive() bool {
-	return f == ZIP
+	return f == ZIP || f == RAR || f == SevenZip
 }
 
-func isLetter(s string) bool {
+// isExtension checks if the string only contains letters and digits, and has at least one letter, such as 7z.
+func isExtension(s string) bool {
+	letter := false
 	for _, r := range s {
-		if !unicode.IsLetter(r) {
+		if unicode.IsLetter(r) {
+			letter = true
+		} else if !unicode.IsDigit(r) {
 			return false
 		}
 	}
-	return true
+	return letter
 }
 
 // LinkExtension the file extension from the link.
@@ -44,7 +56,7 @@ func Extension(filename string) (Format, bool) {
 	start := strings.LastIndex(filename, ".") + 1
 	ext := filename[start:]
 
-	if isLetter(ext) {
+	if isExtension(ext) {
 		return Format(strings.ToLower(ext)), true
 	}
 	return "", false
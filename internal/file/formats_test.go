@@ -0,0 +1,29 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtension(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Format
+		ok       bool
+	}{
+		{filename: "book.EPUB", want: EPUB, ok: true},
+		{filename: "comic.cbr", want: CBR, ok: true},
+		{filename: "archive.part1.7z", want: SevenZip, ok: true},
+		{filename: "novel.fb2", want: FB2, ok: true},
+		{filename: "report.2019", ok: false},
+		{filename: "book.tar-gz", ok: false},
+	}
+	for _, tt := range tests {
+		format, ok := Extension(tt.filename)
+		assert.Equal(t, tt.ok, ok, tt.filename)
+		if tt.ok {
+			assert.Equal(t, tt.want, format, tt.filename)
+		}
+	}
+}
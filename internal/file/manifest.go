@@ -0,0 +1,151 @@
+package file
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const manifestFile = ".bookhunter.manifest"
+
+var (
+	ErrFileMissing      = errors.New("the downloaded file is missing")
+	ErrChecksumMismatch = errors.New("the file checksum doesn't match the manifest")
+)
+
+// ManifestRecord is the integrity information for a downloaded file.
+type ManifestRecord struct {
+	BookID   int64     `json:"id"`
+	Source   string    `json:"source"`
+	FileName string    `json:"filename"` // FileName is the relative path to the download directory.
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Time     time.Time `json:"time"`
+}
+
+// Manifest is an append-only JSON lines file in the download directory.
+type Manifest struct {
+	root string
+	lock sync.Mutex
+}
+
+// NewManifest will create the manifest for the given download directory.
+func NewManifest(root string) *Manifest {
+	return &Manifest{root: root}
+}
+
+func (m *Manifest) path() string {
+	return filepath.Join(m.root, manifestFile)
+}
+
+// Append will save the downloaded output into the manifest.
+func (m *Manifest) Append(id int64, source string, output Output) error {
+	name, err := filepath.Rel(m.root, output.Path)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(&ManifestRecord{
+		BookID:   id,
+		Source:   source,
+		FileName: filepath.ToSlash(name),
+		Size:     output.Size,
+		SHA256:   output.SHA256,
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	f, err := os.OpenFile(m.path(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Records will return all the recorded files. The latest record will be used if the file has been downloaded twice.
+func (m *Manifest) Records() ([]ManifestRecord, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	f, err := os.Open(m.path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []ManifestRecord
+	indexes := map[string]int{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record ManifestRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid manifest record %s: %w", scanner.Text(), err)
+		}
+
+		if i, ok := indexes[record.FileName]; ok {
+			records[i] = record
+		} else {
+			indexes[record.FileName] = len(records)
+			records = append(records, record)
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// Verify will check the file on the disk against the given record.
+func (m *Manifest) Verify(record *ManifestRecord) error {
+	path := filepath.Join(m.root, filepath.FromSlash(record.FileName))
+	size, checksum, err := Checksum(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrFileMissing
+		}
+		return err
+	}
+
+	if size != record.Size {
+		return fmt.Errorf("%w: expected %d bytes, got %d bytes", ErrSizeMismatch, record.Size, size)
+	}
+	if checksum != record.SHA256 {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// Checksum will calculate the size and hex encoded SHA-256 for the given file.
+func Checksum(path string) (int64, string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	digest := sha256.New()
+	if err := hashFile(digest, path); err != nil {
+		return 0, "", err
+	}
+
+	return stat.Size(), hex.EncodeToString(digest.Sum(nil)), nil
+}
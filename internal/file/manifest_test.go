@@ -0,0 +1,55 @@
+package file
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest_Verify(t *testing.T) {
+	root := t.TempDir()
+	creator := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}})
+
+	content := []byte("bookhunter manifest content")
+	w, err := creator.NewWriter(1, 1, "book", "", EPUB, int64(len(content)), nil)
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Len(t, w.Outputs(), 1)
+
+	manifest := NewManifest(root)
+	assert.NoError(t, manifest.Append(1, "talebook", w.Outputs()[0]))
+
+	records, err := manifest.Records()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "book.epub", records[0].FileName)
+	assert.NoError(t, manifest.Verify(&records[0]))
+
+	// Tamper the downloaded file.
+	assert.NoError(t, os.WriteFile(w.Outputs()[0].Path, []byte("bookhunter manifest CONTENT"), 0o644))
+	assert.ErrorIs(t, manifest.Verify(&records[0]), ErrChecksumMismatch)
+
+	assert.NoError(t, os.Remove(w.Outputs()[0].Path))
+	assert.ErrorIs(t, manifest.Verify(&records[0]), ErrFileMissing)
+}
+
+func TestWriter_SizeMismatch(t *testing.T) {
+	root := t.TempDir()
+	creator := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}})
+
+	w, err := creator.NewWriter(1, 1, "truncated", "", EPUB, 100, nil)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("too short"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, w.Close(), ErrSizeMismatch)
+	assert.Empty(t, w.Outputs())
+
+	// The partial file should be resumed in the next download.
+	w, err = creator.NewWriter(1, 1, "truncated", "", EPUB, 100, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), w.Offset())
+	assert.NoError(t, w.Abort())
+}
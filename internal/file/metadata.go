@@ -0,0 +1,162 @@
+package file
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata is the book information exposed by the fetcher services, the fields could be empty.
+type Metadata struct {
+	Title       string    `json:"title"`
+	Authors     []string  `json:"authors,omitempty"`
+	Publisher   string    `json:"publisher,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Published   time.Time `json:"published,omitempty"`
+	Series      string    `json:"series,omitempty"`
+	Source      string    `json:"source,omitempty"` // The fetcher service which provides the book.
+	ID          int64     `json:"id,omitempty"`     // The book id in the fetcher service.
+	ISBN        string    `json:"isbn,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Cover       string    `json:"cover,omitempty"` // The link of the cover image.
+	CoverImage  []byte    `json:"-"`               // The content of the cover image, it's loaded after the download.
+}
+
+type MetadataFormat string // The file format of the metadata sidecar.
+
+const (
+	MetadataNone MetadataFormat = ""     // Don't save the metadata.
+	MetadataOPF  MetadataFormat = "opf"  // The Calibre compatible OPF file.
+	MetadataJSON MetadataFormat = "json" // The JSON file of the Metadata.
+)
+
+// ParseMetadataFormat will create the metadata sidecar format from the string.
+func ParseMetadataFormat(format string) (MetadataFormat, error) {
+	switch f := MetadataFormat(strings.ToLower(format)); f {
+	case MetadataNone, MetadataOPF, MetadataJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid metadata format %s, it should be opf or json", format)
+	}
+}
+
+// SidecarPath is the path of the file which has the same name with the book but a different extension.
+// The books may share the same directory, so the sidecar can't use a fixed name like metadata.opf in Calibre.
+func SidecarPath(path, ext string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ext
+}
+
+// SaveSidecar will write the metadata next to the book file. The cover is the cover image path, it's optional.
+func SaveSidecar(path string, format MetadataFormat, m *Metadata, cover string) (string, error) {
+	var content []byte
+	var err error
+	switch format {
+	case MetadataOPF:
+		content, err = m.OPF(filepath.Base(cover))
+	case MetadataJSON:
+		content, err = json.MarshalIndent(m, "", "  ")
+	default:
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	sidecar := SidecarPath(path, "."+string(format))
+	return sidecar, os.WriteFile(sidecar, append(content, '\n'), 0o644)
+}
+
+type opfPackage struct {
+	XMLName  xml.Name    `xml:"package"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	UniqueID string      `xml:"unique-identifier,attr"`
+	Version  string      `xml:"version,attr"`
+	Metadata opfMetadata `xml:"metadata"`
+	Guide    *opfGuide   `xml:"guide,omitempty"`
+}
+
+type opfMetadata struct {
+	DC          string          `xml:"xmlns:dc,attr"`
+	OPF         string          `xml:"xmlns:opf,attr"`
+	Identifiers []opfIdentifier `xml:"dc:identifier"`
+	Title       string          `xml:"dc:title"`
+	Creators    []opfCreator    `xml:"dc:creator"`
+	Publisher   string          `xml:"dc:publisher,omitempty"`
+	Date        string          `xml:"dc:date,omitempty"`
+	Language    string          `xml:"dc:language,omitempty"`
+	Description string          `xml:"dc:description,omitempty"`
+	Subjects    []string        `xml:"dc:subject"`
+	Metas       []opfMeta       `xml:"meta"`
+}
+
+type opfIdentifier struct {
+	ID     string `xml:"id,attr,omitempty"`
+	Scheme string `xml:"opf:scheme,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type opfCreator struct {
+	Role  string `xml:"opf:role,attr"`
+	Value string `xml:",chardata"`
+}
+
+type opfMeta struct {
+	Name    string `xml:"name,attr"`
+	Content string `xml:"content,attr"`
+}
+
+type opfGuide struct {
+	References []opfReference `xml:"reference"`
+}
+
+type opfReference struct {
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+	Href  string `xml:"href,attr"`
+}
+
+// OPF will generate the OPF file which could be read by Calibre, the cover is the relative path of the cover image.
+func (m *Metadata) OPF(cover string) ([]byte, error) {
+	p := &opfPackage{
+		Xmlns:    "http://www.idpf.org/2007/opf",
+		UniqueID: "bookhunter_id",
+		Version:  "2.0",
+		Metadata: opfMetadata{
+			DC:          "http://purl.org/dc/elements/1.1/",
+			OPF:         "http://www.idpf.org/2007/opf",
+			Identifiers: []opfIdentifier{{ID: "bookhunter_id", Scheme: m.Source, Value: strconv.FormatInt(m.ID, 10)}},
+			Title:       m.Title,
+			Publisher:   m.Publisher,
+			Language:    m.Language,
+			Description: m.Description,
+			Subjects:    m.Tags,
+		},
+	}
+	if m.ISBN != "" {
+		p.Metadata.Identifiers = append(p.Metadata.Identifiers, opfIdentifier{Scheme: "ISBN", Value: m.ISBN})
+	}
+	for _, author := range m.Authors {
+		p.Metadata.Creators = append(p.Metadata.Creators, opfCreator{Role: "aut", Value: author})
+	}
+	if !m.Published.IsZero() {
+		p.Metadata.Date = m.Published.Format(time.RFC3339)
+	}
+	if m.Series != "" {
+		p.Metadata.Metas = append(p.Metadata.Metas, opfMeta{Name: "calibre:series", Content: m.Series})
+	}
+	if cover != "" && cover != "." {
+		p.Guide = &opfGuide{References: []opfReference{{Type: "cover", Title: "Cover", Href: cover}}}
+	}
+
+	content, err := xml.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
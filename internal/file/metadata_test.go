@@ -0,0 +1,54 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveSidecar_OPF(t *testing.T) {
+	root := t.TempDir()
+	metadata := &Metadata{
+		Title:     "Foundation & Empire",
+		Authors:   []string{"Isaac Asimov"},
+		Publisher: "Gnome Press",
+		Tags:      []string{"Science Fiction"},
+		Published: time.Date(1952, 1, 1, 0, 0, 0, 0, time.UTC),
+		Series:    "Foundation",
+		Source:    "talebook",
+		ID:        42,
+		ISBN:      "9780553293371",
+	}
+
+	path, err := SaveSidecar(filepath.Join(root, "book.epub"), MetadataOPF, metadata, filepath.Join(root, "book.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "book.opf"), path)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="bookhunter_id" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:identifier id="bookhunter_id" opf:scheme="talebook">42</dc:identifier>
+    <dc:identifier opf:scheme="ISBN">9780553293371</dc:identifier>
+    <dc:title>Foundation &amp; Empire</dc:title>
+    <dc:creator opf:role="aut">Isaac Asimov</dc:creator>
+    <dc:publisher>Gnome Press</dc:publisher>
+    <dc:date>1952-01-01T00:00:00Z</dc:date>
+    <dc:subject>Science Fiction</dc:subject>
+    <meta name="calibre:series" content="Foundation"></meta>
+  </metadata>
+  <guide>
+    <reference type="cover" title="Cover" href="book.jpg"></reference>
+  </guide>
+</package>
+`, string(content))
+
+	// The sidecar isn't saved if the format is empty.
+	path, err = SaveSidecar(filepath.Join(root, "book.epub"), MetadataNone, metadata, "")
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+}
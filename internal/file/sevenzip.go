@@ -0,0 +1,80 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// walkSevenZip reads the 7z archive by github.com/bodgit/sevenzip, which supports the solid archives, the BCJ and BCJ2
+// filters and all the common coders of 7-Zip. The encrypted archives aren't supported.
+func walkSevenZip(path string, walk walkFunc) error {
+	r, err := sevenzip.OpenReader(path)
+	if err != nil {
+		return sevenZipError(err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for _, f := range r.File {
+		if err := walkSevenZipFile(f, walk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Closure to release the reader of the file before opening the next one, the decompressed stream could be reused.
+func walkSevenZipFile(f *sevenzip.File, walk walkFunc) error {
+	rc, err := f.Open()
+	if err != nil {
+		return sevenZipError(err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	// The files in the same folder are compressed in one stream, the skipped files are still decompressed and counted.
+	entry := &archiveEntry{name: escape(f.Name), mode: f.Mode(), solid: true}
+	return walk(entry, &sevenZipReader{r: rc, size: f.UncompressedSize, crc: f.CRC32, digest: crc32.NewIEEE()})
+}
+
+// sevenZipError classifies the errors of the 7z reader.
+func sevenZipError(err error) error {
+	var re *sevenzip.ReadError
+	if errors.As(err, &re) && re.Encrypted {
+		return fmt.Errorf("%w: the encrypted 7z archive: %w", ErrUnsupportedArchive, err)
+	}
+	return fmt.Errorf("%w: %w", ErrCorruptArchive, err)
+}
+
+// sevenZipReader verifies the CRC of the file after its content has been fully read,
+// the reader of the library doesn't check it.
+type sevenZipReader struct {
+	r      io.Reader
+	size   uint64
+	crc    uint32 // The zero CRC means it's not defined in the archive.
+	digest hash.Hash32
+	read   uint64
+}
+
+func (r *sevenZipReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.read += uint64(n)
+	_, _ = r.digest.Write(b[:n])
+
+	if err == io.EOF {
+		if r.read != r.size {
+			return n, fmt.Errorf("%w: unexpected end of the file", ErrCorruptArchive)
+		}
+		if r.crc != 0 && r.digest.Sum32() != r.crc {
+			return n, fmt.Errorf("%w: the CRC of the file doesn't match", ErrCorruptArchive)
+		}
+	} else if err != nil {
+		return n, sevenZipError(err)
+	}
+
+	return n, err
+}
@@ -0,0 +1,169 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTemplate = errors.New("invalid name template")
+
+// The placeholders in the name template.
+var placeholders = map[string]func(v *templateValues) string{
+	"id":        func(v *templateValues) string { return strconv.FormatInt(v.id, 10) },
+	"ext":       func(v *templateValues) string { return string(v.format) },
+	"name":      func(v *templateValues) string { return v.name },
+	"title":     func(v *templateValues) string { return v.metadata.Title },
+	"author":    func(v *templateValues) string { return first(v.metadata.Authors) },
+	"authors":   func(v *templateValues) string { return strings.Join(v.metadata.Authors, ", ") },
+	"publisher": func(v *templateValues) string { return v.metadata.Publisher },
+	"series":    func(v *templateValues) string { return v.metadata.Series },
+	"tag":       func(v *templateValues) string { return first(v.metadata.Tags) },
+	"tags":      func(v *templateValues) string { return strings.Join(v.metadata.Tags, ", ") },
+	"date":      func(v *templateValues) string { return formatTime(v.metadata.Published, time.DateOnly) },
+	"year":      func(v *templateValues) string { return formatTime(v.metadata.Published, "2006") },
+	"source":    func(v *templateValues) string { return v.metadata.Source },
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func formatTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+type templateValues struct {
+	id       int64
+	name     string // The file name without the extension.
+	format   Format
+	metadata *Metadata
+}
+
+// NameTemplate defines the file path of the downloaded book, such as {author}/{series}/{title} - {id}.{ext}.
+type NameTemplate struct {
+	segments [][]templatePart // The directories and the file name.
+}
+
+// templatePart is a literal text or a placeholder.
+type templatePart struct {
+	text        string
+	placeholder bool
+}
+
+// ParseNameTemplate will validate the template, the .{ext} suffix will be added if the template doesn't have it.
+func ParseNameTemplate(template string) (*NameTemplate, error) {
+	template = strings.TrimSpace(template)
+	if template == "" {
+		return nil, nil
+	}
+	if !strings.Contains(template, "{ext}") {
+		template += ".{ext}"
+	}
+	if strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("%w: %s, it should be a relative path", ErrInvalidTemplate, template)
+	}
+
+	t := &NameTemplate{}
+	for _, segment := range strings.Split(template, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return nil, fmt.Errorf("%w: %s, it contains the empty or relative directory", ErrInvalidTemplate, template)
+		}
+
+		var parts []templatePart
+		for segment != "" {
+			start := strings.IndexAny(segment, "{}")
+			if start < 0 {
+				parts = append(parts, templatePart{text: segment})
+				break
+			}
+			end := strings.Index(segment, "}")
+			if segment[start] != '{' || end < start {
+				return nil, fmt.Errorf("%w: %s, the braces are not paired", ErrInvalidTemplate, template)
+			}
+
+			name := segment[start+1 : end]
+			if _, ok := placeholders[name]; !ok {
+				return nil, fmt.Errorf("%w: unknown placeholder {%s}", ErrInvalidTemplate, name)
+			}
+			if start > 0 {
+				parts = append(parts, templatePart{text: segment[:start]})
+			}
+			parts = append(parts, templatePart{text: name, placeholder: true})
+			segment = segment[end+1:]
+		}
+		t.segments = append(t.segments, parts)
+	}
+
+	return t, nil
+}
+
+// Execute will generate the relative file path. The directories with the empty placeholders are skipped,
+// the book id is used as the file name if it's empty.
+func (t *NameTemplate) Execute(id int64, name string, format Format, metadata *Metadata) string {
+	if metadata == nil {
+		metadata = &Metadata{}
+	}
+	name = strings.TrimSuffix(name, "."+string(format))
+	if metadata.Title == "" {
+		m := *metadata
+		m.Title = name
+		metadata = &m
+	}
+	v := &templateValues{id: id, name: name, format: format, metadata: metadata}
+
+	var segments []string
+	for i, parts := range t.segments {
+		segment := ""
+		empty := false
+		for _, part := range parts {
+			if !part.placeholder {
+				segment += part.text
+				continue
+			}
+			value := strings.TrimSpace(placeholders[part.text](v))
+			empty = empty || value == ""
+			segment += value
+		}
+
+		// Escape the segment for avoiding the illegal characters and the nested directories in the values.
+		segment = strings.TrimSpace(replacer.Replace(segment))
+		if i < len(t.segments)-1 {
+			// Skip the directory with the missing metadata, the relative directories are not allowed.
+			if !empty && strings.Trim(segment, ".") != "" {
+				segments = append(segments, truncateName(segment, ""))
+			}
+			continue
+		}
+
+		ext := "." + string(format)
+		if strings.TrimSpace(strings.TrimSuffix(segment, ext)) == "" {
+			segment = strconv.FormatInt(id, 10) + ext
+		}
+		segments = append(segments, truncateName(segment, format))
+	}
+
+	return path.Join(segments...)
+}
+
+// truncateName limits the name size but keeps the extension.
+func truncateName(name string, format Format) string {
+	ext := ""
+	if format != "" && strings.HasSuffix(name, "."+string(format)) {
+		ext = "." + string(format)
+		name = strings.TrimSuffix(name, ext)
+	}
+	if limit := maxLength - len([]rune(ext)); len([]rune(name)) > limit {
+		name = strings.TrimSpace(string([]rune(name)[:limit]))
+	}
+	return name + ext
+}
@@ -0,0 +1,91 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNameTemplate(t *testing.T) {
+	for _, template := range []string{"/{title}", "{author}/../{title}", "{author}//{title}", "{title", "{title}}", "{isbn}"} {
+		_, err := ParseNameTemplate(template)
+		assert.ErrorIs(t, err, ErrInvalidTemplate, template)
+	}
+
+	template, err := ParseNameTemplate("")
+	assert.NoError(t, err)
+	assert.Nil(t, template)
+}
+
+func TestNameTemplate_Execute(t *testing.T) {
+	metadata := &Metadata{
+		Title:     "Foundation",
+		Authors:   []string{"Isaac Asimov", "Someone Else"},
+		Publisher: "Gnome Press",
+		Series:    "Foundation",
+		Tags:      []string{"Science Fiction"},
+		Published: time.Date(1951, 6, 1, 0, 0, 0, 0, time.UTC),
+		Source:    "talebook",
+	}
+
+	for template, expected := range map[string]string{
+		"{author}/{series}/{title} - {id}.{ext}": "Isaac Asimov/Foundation/Foundation - 12.epub",
+		"{source}/{year}/{title}":                "talebook/1951/Foundation.epub",
+		"{publisher} {date} {tags}":              "Gnome Press 1951-06-01 Science Fiction.epub",
+		"{authors}/{name}":                       "Isaac Asimov, Someone Else/foundation.epub",
+	} {
+		nt, err := ParseNameTemplate(template)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, nt.Execute(12, "foundation.epub", EPUB, metadata), template)
+	}
+
+	nt, err := ParseNameTemplate("{author}/{series}/{title}")
+	assert.NoError(t, err)
+
+	// The directories with the missing metadata are skipped, and the title falls back to the file name.
+	assert.Equal(t, "book.epub", nt.Execute(12, "book.epub", EPUB, nil))
+	assert.Equal(t, "12.epub", nt.Execute(12, "", EPUB, &Metadata{Title: " "}))
+
+	// The values can't create the nested or relative directories.
+	assert.Equal(t, "a b.epub", nt.Execute(12, "", EPUB, &Metadata{Authors: []string{".."}, Title: "a/b"}))
+
+	// The long name is truncated with the extension kept.
+	path := nt.Execute(12, "", EPUB, &Metadata{Title: strings.Repeat("书", 100)})
+	assert.Equal(t, maxLength, len([]rune(path)))
+	assert.True(t, strings.HasSuffix(path, ".epub"))
+}
+
+func TestWriter_NameTemplate(t *testing.T) {
+	root := t.TempDir()
+	template, err := ParseNameTemplate("{author}/{title}")
+	assert.NoError(t, err)
+	c := NewCreator(&CreatorConfig{DownloadPath: root, Formats: []Format{EPUB}, NameTemplate: template})
+	metadata := &Metadata{Title: "Dune", Authors: []string{"Frank Herbert"}}
+
+	// The existing files are kept and the new file is saved with a suffix.
+	for i, expected := range []string{"Dune.epub", "Dune (1).epub", "Dune (2).epub"} {
+		w, err := c.NewWriter(int64(i+1), 3, "dune", "", EPUB, 4, metadata)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte("dune"))
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		assert.Equal(t, filepath.Join(root, "Frank Herbert", expected), w.Outputs()[0].Path)
+	}
+
+	// The partial file is resumed instead of creating a new file.
+	w, err := c.NewWriter(4, 4, "dune", "", EPUB, 4, metadata)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("du"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Abort())
+	w, err = c.NewWriter(4, 4, "dune", "", EPUB, 4, metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), w.Offset())
+	assert.NoError(t, w.Abort())
+	_, err = os.Stat(filepath.Join(root, "Frank Herbert", "Dune (3).epub.part"))
+	assert.NoError(t, err)
+}
@@ -1,6 +1,11 @@
 package file
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
@@ -8,15 +13,19 @@ import (
 	"strings"
 
 	"github.com/schollz/progressbar/v3"
+	"golang.org/x/text/encoding"
 
 	"github.com/bookstairs/bookhunter/internal/log"
 )
 
 const (
-	maxLength = 60
-	empty     = " "
+	maxLength  = 60
+	empty      = " "
+	partSuffix = ".part"
 )
 
+var ErrSizeMismatch = errors.New("the downloaded file size doesn't match the expected size")
+
 // escape the filename in *nix like systems and limit the max name size.
 func escape(filename string) string {
 	filename = replacer.Replace(filename)
@@ -28,31 +37,72 @@ func escape(filename string) string {
 	}
 }
 
-func NewCreator(rename bool, downloadPath string, formats []Format, extract bool) Creator {
+// CreatorConfig is used to define how to save the downloaded files.
+type CreatorConfig struct {
+	Rename       bool          // Rename the file by using book ID.
+	NameTemplate *NameTemplate // The template for generating the file path, it's optional.
+	DownloadPath string        // The path for storing the file.
+	Formats      []Format      // The formats that the user wants.
+	Extract      bool          // Extract the archives after download.
+	Limits       ExtractLimits // The limits for extracting the archives.
+	Dedupe       Dedupe        // The policy for the files which are identical to the indexed files.
+	Conflict     Conflict      // The policy for the existing files which have the same name.
+	Embed        bool          // Embed the metadata into the downloaded EPUB files.
+	Index        *Index        // The content-addressed index for detecting the duplicated files, it's optional.
+
+	// The encoding of the file names in the zip archives, it will be detected if it's nil.
+	ArchiveEncoding encoding.Encoding
+}
+
+func NewCreator(c *CreatorConfig) Creator {
 	fs := make(map[Format]bool)
-	for _, format := range formats {
+	for _, format := range c.Formats {
 		fs[format] = true
 	}
 
-	return &creator{rename: rename, downloadPath: downloadPath, formats: fs, extract: extract}
+	return &creator{
+		rename:       c.Rename,
+		template:     c.NameTemplate,
+		downloadPath: c.DownloadPath,
+		formats:      fs,
+		extract:      c.Extract,
+		limits:       c.Limits,
+		encoding:     c.ArchiveEncoding,
+		dedupe:       c.Dedupe,
+		conflict:     c.Conflict,
+		embed:        c.Embed,
+		index:        c.Index,
+	}
 }
 
 type Creator interface {
-	NewWriter(id, total int64, name, subPath string, format Format, size int64) (Writer, error)
+	NewWriter(id, total int64, name, subPath string, format Format, size int64, metadata *Metadata) (Writer, error)
 }
 
 type creator struct {
 	rename       bool
+	template     *NameTemplate
 	extract      bool
+	limits       ExtractLimits
+	encoding     encoding.Encoding
 	formats      map[Format]bool
 	downloadPath string
+	dedupe       Dedupe
+	conflict     Conflict
+	embed        bool
+	index        *Index
 }
 
-func (c *creator) NewWriter(id, total int64, name, subPath string, format Format, size int64) (Writer, error) {
+func (c *creator) NewWriter(id, total int64, name, subPath string, format Format, size int64, metadata *Metadata) (Writer, error) {
 	// Rename if it was required.
 	filename := strconv.FormatInt(id, 10)
 	if c.rename {
 		filename = filename + "." + string(format)
+	} else if c.template != nil {
+		// The template defines the whole relative path, the sub path is ignored.
+		filename = c.template.Execute(id, name, format, metadata)
+		subPath = filepath.Dir(filepath.FromSlash(filename))
+		filename = filepath.Base(filepath.FromSlash(filename))
 	} else if strings.HasSuffix(name, "."+string(format)) {
 		filename = name
 	} else {
@@ -65,7 +115,7 @@ func (c *creator) NewWriter(id, total int64, name, subPath string, format Format
 
 	// Create the download path.
 	downloadPath := c.downloadPath
-	if subPath != "" {
+	if subPath != "" && subPath != "." {
 		downloadPath = filepath.Join(downloadPath, subPath)
 		err := os.MkdirAll(downloadPath, 0o755)
 		if err != nil {
@@ -73,31 +123,60 @@ func (c *creator) NewWriter(id, total int64, name, subPath string, format Format
 		}
 	}
 
-	// Generate the file path.
-	path := filepath.Join(downloadPath, filename)
+	// Generate the file path and resolve the conflict with the existing file.
+	path, existing, err := c.conflict.resolve(filepath.Join(downloadPath, filename), size)
+	if err != nil {
+		return nil, err
+	}
+	filename = filepath.Base(path)
+
+	// Create file io. and remember to close it manually.
+	// The content will be appended to the partial file if it's existed.
+	file, err := os.OpenFile(path+partSuffix, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	offset := stat.Size()
 
-	// Remove the exist file.
-	if _, err := os.Stat(path); err == nil {
-		if err := os.Remove(path); err != nil {
+	// Calculate the checksum of the downloaded content.
+	digest := sha256.New()
+	if offset > 0 {
+		if err := hashFile(digest, path+partSuffix); err != nil {
+			_ = file.Close()
 			return nil, err
 		}
 	}
 
 	// Add download progress, no need to close.
 	bar := log.NewProgressBar(id, total, name, size)
-
-	// Create file io. and remember to close it manually.
-	file, err := os.Create(path)
-	if err != nil {
-		return nil, err
+	if offset > 0 {
+		log.Debugf("Resume the download of %s from %d bytes.", filename, offset)
+		_ = bar.Set64(offset)
 	}
 
 	return &writer{
 		file:     file,
 		name:     filename,
 		download: downloadPath,
+		offset:   offset,
+		size:     size,
+		digest:   digest,
+		format:   format,
+		existing: existing,
+		metadata: metadata,
+		embed:    c.embed && metadata != nil,
 		extract:  c.extract && format.Archive(),
+		limits:   c.limits,
+		encoding: c.encoding,
 		formats:  c.formats,
+		dedupe:   c.dedupe,
+		conflict: c.conflict,
+		index:    c.index,
 		bar:      bar,
 	}, nil
 }
@@ -106,14 +185,51 @@ type Writer interface {
 	io.Writer
 	io.Closer
 	SetSize(int64)
+
+	// Offset is the size of the written content, the download should be resumed from this offset.
+	Offset() int64
+
+	// Written is the size of the content written by this writer, the resumed content isn't included.
+	Written() int64
+
+	// Reset will discard the written content for downloading the file from the beginning.
+	Reset() error
+
+	// Abort will close the writer and keep the partial file for resuming the download next time.
+	Abort() error
+
+	// Outputs are the saved files after the writer has been closed successfully.
+	Outputs() []Output
+}
+
+// Output is a completed file on the disk.
+type Output struct {
+	Path   string // The file path.
+	Size   int64  // The file size in bytes.
+	SHA256 string // The hex encoded SHA-256 checksum.
 }
 
 type writer struct {
 	file     *os.File
 	name     string
 	download string
+	offset   int64
+	written  int64
+	size     int64
+	digest   hash.Hash
+	outputs  []Output
+	format   Format
+	existing string // The existing file which should be compared after the download.
+	metadata *Metadata
+	embed    bool
 	formats  map[Format]bool
 	extract  bool
+	limits   ExtractLimits
+	encoding encoding.Encoding
+	dedupe   Dedupe
+	conflict Conflict // The policy for the existing files which have the same name with the extracted files.
+	linked   []string // The outputs which are the hard links to the identical files.
+	index    *Index
 	bar      *progressbar.ProgressBar
 }
 
@@ -124,29 +240,141 @@ func (p *writer) Close() error {
 		return err
 	}
 
+	// Check the file is truncated or not.
+	if p.size > 0 && p.offset != p.size {
+		// The overflowed file is broken, no need to resume it.
+		if p.offset > p.size {
+			_ = os.Remove(p.partPath())
+		}
+		return fmt.Errorf("%w: %s, expected %d bytes, got %d bytes", ErrSizeMismatch, p.name, p.size, p.offset)
+	}
+
+	// Move the completed file to its final path.
+	if err := os.Rename(p.partPath(), p.filePath()); err != nil {
+		return err
+	}
+
+	// Keep the existing file if it's identical to the downloaded file.
+	if p.existing != "" && sameFile(p.existing, p.digest.Sum(nil)) {
+		_ = os.Remove(p.filePath())
+		return fmt.Errorf("%w: %s", ErrFileExisted, p.existing)
+	}
+
 	// Extract the file if user enabled this.
 	if p.extract {
 		if err := p.decompress(); err != nil {
-			log.Fatal(err)
-			return nil
+			// Remove the extracted files and keep the archive for checking it manually.
+			for _, output := range p.outputs {
+				_ = os.Remove(output.Path)
+			}
+			p.outputs = nil
+			return fmt.Errorf("failed to extract %s: %w", p.name, err)
 		}
 
 		// Remove the compress files.
 		_ = os.Remove(p.filePath())
+	} else {
+		p.outputs = append(p.outputs, Output{
+			Path:   p.filePath(),
+			Size:   p.offset,
+			SHA256: hex.EncodeToString(p.digest.Sum(nil)),
+		})
+	}
+
+	// Deduplicate by the downloaded content, the embedded metadata differs between the sources.
+	var fresh []Output
+	p.outputs, fresh = p.deduplicate(p.outputs)
+	if p.embed {
+		p.embedMetadata()
 	}
+	p.indexOutputs(fresh)
 
 	return err
 }
 
+func (p *writer) Abort() error {
+	_ = p.bar.Close()
+	if err := p.file.Close(); err != nil {
+		return err
+	}
+
+	// Nothing has been downloaded, no need to keep the partial file.
+	if p.offset == 0 {
+		return os.Remove(p.partPath())
+	}
+
+	return nil
+}
+
 func (p *writer) filePath() string {
 	return filepath.Join(p.download, p.name)
 }
 
+func (p *writer) partPath() string {
+	return p.filePath() + partSuffix
+}
+
 func (p *writer) Write(b []byte) (n int, err error) {
 	_, _ = p.bar.Write(b)
-	return p.file.Write(b)
+	n, err = p.file.Write(b)
+	_, _ = p.digest.Write(b[:n])
+	p.offset += int64(n)
+	p.written += int64(n)
+	return
+}
+
+func (p *writer) Offset() int64 {
+	return p.offset
+}
+
+func (p *writer) Written() int64 {
+	return p.written
+}
+
+func (p *writer) Reset() error {
+	if err := p.file.Truncate(0); err != nil {
+		return err
+	}
+	p.offset = 0
+	p.digest.Reset()
+	p.bar.Reset()
+
+	return nil
 }
 
 func (p *writer) SetSize(i int64) {
+	p.size = i
 	p.bar.ChangeMax64(i)
 }
+
+func (p *writer) Outputs() []Output {
+	return p.outputs
+}
+
+// UniquePath will find a file path which isn't existed by adding the " (1)" like suffix to the file name.
+// The path with a partial file is returned for resuming the download.
+func UniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path + partSuffix); err == nil {
+			return path
+		}
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			return path
+		}
+		path = base + " (" + strconv.Itoa(i) + ")" + ext
+	}
+}
+
+// hashFile will write the file content into the given hash.
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = io.Copy(h, f)
+	return err
+}
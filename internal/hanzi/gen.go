@@ -0,0 +1,74 @@
+//go:build ignore
+
+// This program generates the pinyin.txt and simplified.txt from the transliterators of ICU, uconv is required.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// The CJK Unified Ideographs block.
+const first, last = 0x4e00, 0x9fff
+
+func transliterate(id string, chars []rune) []string {
+	input := new(bytes.Buffer)
+	for _, c := range chars {
+		input.WriteString(string(c) + "\n")
+	}
+	cmd := exec.Command("uconv", "-x", id)
+	cmd.Stdin = input
+	output, err := cmd.Output()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	if len(lines) != len(chars) {
+		log.Fatalf("unexpected %d lines for %d characters", len(lines), len(chars))
+	}
+	return lines
+}
+
+func main() {
+	var chars []rune
+	for c := rune(first); c <= last; c++ {
+		chars = append(chars, c)
+	}
+
+	// The characters are grouped by the pinyin without tones.
+	groups := map[string][]rune{}
+	for i, py := range transliterate("Han-Latin; Latin-ASCII; Lower", chars) {
+		if py != "" && strings.Trim(py, "abcdefghijklmnopqrstuvwxyz") == "" {
+			groups[py] = append(groups[py], chars[i])
+		}
+	}
+	pinyin := new(bytes.Buffer)
+	syllables := make([]string, 0, len(groups))
+	for py := range groups {
+		syllables = append(syllables, py)
+	}
+	slices.Sort(syllables)
+	for _, py := range syllables {
+		fmt.Fprintf(pinyin, "%s\t%s\n", py, string(groups[py]))
+	}
+
+	// Only the one-to-one conversions are kept.
+	simplified := new(bytes.Buffer)
+	for i, s := range transliterate("Traditional-Simplified", chars) {
+		if r := []rune(s); len(r) == 1 && r[0] != chars[i] {
+			fmt.Fprintf(simplified, "%c\t%c\n", chars[i], r[0])
+		}
+	}
+
+	if err := os.WriteFile("pinyin.txt", pinyin.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("simplified.txt", simplified.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,72 @@
+// Package hanzi converts the Chinese characters into the simplified ones and their pinyin.
+// The tables are generated from the transliterators of ICU, every character only has its most common pinyin.
+package hanzi
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:generate go run gen.go
+
+var (
+	//go:embed pinyin.txt
+	pinyinTable string
+	//go:embed simplified.txt
+	simplifiedTable string
+
+	pinyins    map[rune]string
+	simplified map[rune]rune
+	loadTables sync.Once
+)
+
+// load parses the tables in the first use.
+func load() {
+	loadTables.Do(func() {
+		pinyins = make(map[rune]string, 21000)
+		for _, line := range strings.Split(pinyinTable, "\n") {
+			syllable, chars, _ := strings.Cut(line, "\t")
+			for _, c := range chars {
+				pinyins[c] = syllable
+			}
+		}
+
+		simplified = make(map[rune]rune, 3000)
+		for _, line := range strings.Split(simplifiedTable, "\n") {
+			if t, s, ok := strings.Cut(line, "\t"); ok {
+				simplified[[]rune(t)[0]] = []rune(s)[0]
+			}
+		}
+	})
+}
+
+// Simplify converts the traditional Chinese characters into the simplified ones.
+func Simplify(s string) string {
+	load()
+	return strings.Map(func(r rune) rune {
+		if c, ok := simplified[r]; ok {
+			return c
+		}
+		return r
+	}, s)
+}
+
+// Pinyin returns the full pinyin and the initials of the text without tones and spaces, such as sanguoyanyi and
+// sgyy for 三国演义. The other characters are kept and converted into the lower case.
+func Pinyin(s string) (full, initials string) {
+	load()
+	var f, i strings.Builder
+	for _, r := range s {
+		if py, ok := pinyins[r]; ok {
+			f.WriteString(py)
+			i.WriteByte(py[0])
+			continue
+		}
+		r = unicode.ToLower(r)
+		f.WriteRune(r)
+		i.WriteRune(r)
+	}
+	return f.String(), i.String()
+}
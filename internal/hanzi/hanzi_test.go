@@ -0,0 +1,21 @@
+package hanzi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimplify(t *testing.T) {
+	assert.Equal(t, "三国演义 The Go 编程语言", Simplify("三國演義 The Go 編程語言"))
+}
+
+func TestPinyin(t *testing.T) {
+	full, initials := Pinyin("三国演义 Go语言")
+	assert.Equal(t, "sanguoyanyi goyuyan", full)
+	assert.Equal(t, "sgyy goyy", initials)
+
+	// The traditional characters have the pinyin too.
+	full, _ = Pinyin("國")
+	assert.Equal(t, "guo", full)
+}
@@ -0,0 +1,146 @@
+package hook
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+// calibreStub is a calibre content server which holds the books in memory.
+type calibreStub struct {
+	queries []string
+	books   map[string][]byte
+	fields  map[string]map[string]any
+}
+
+func (s *calibreStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "secret" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="calibre"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/calibre/ajax/search/Books":
+		query := r.URL.Query().Get("query")
+		s.queries = append(s.queries, query)
+		ids := []int{}
+		if strings.Contains(query, "9780141439518") {
+			ids = append(ids, 1)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"book_ids": ids})
+	case strings.HasPrefix(r.URL.Path, "/calibre/cdb/add-book/"):
+		content, _ := io.ReadAll(r.Body)
+		s.books[r.URL.Path] = content
+		_ = json.NewEncoder(w).Encode(map[string]any{"book_id": 2})
+	case r.URL.Path == "/calibre/cdb/set-fields/2/Books":
+		var body map[string]map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.fields[r.URL.Path] = body["changes"]
+		_ = json.NewEncoder(w).Encode(map[string]any{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestCalibreHook(t *testing.T) {
+	stub := &calibreStub{books: map[string][]byte{}, fields: map[string]map[string]any{}}
+	server := httptest.NewTLSServer(stub)
+	defer server.Close()
+
+	for _, arg := range []string{"", "localhost:8080", "ftp://localhost"} {
+		_, err := newCalibreHook(arg)
+		assert.Error(t, err, arg)
+	}
+
+	h, err := newCalibreHook(strings.Replace(server.URL, "https://", "https://admin:secret@", 1) + "/calibre?library=Books")
+	assert.NoError(t, err)
+	h.(*calibreHook).client.SetTLSClientConfig(server.Client().Transport.(*http.Transport).TLSClientConfig)
+
+	path := filepath.Join(t.TempDir(), "Emma.epub")
+	assert.NoError(t, os.WriteFile(path, []byte("emma"), 0o644))
+
+	// The book with the existing ISBN is skipped.
+	existed := &Book{ID: 1, Format: file.EPUB, Path: path, Metadata: &file.Metadata{Title: "Pride", ISBN: "9780141439518"}}
+	assert.NoError(t, h.Run(context.Background(), existed))
+	assert.Empty(t, stub.books)
+
+	book := &Book{ID: 7, Format: file.EPUB, Path: path, Metadata: &file.Metadata{
+		Title:   `Emma "Woodhouse"`,
+		Authors: []string{"Jane Austen"},
+		Tags:    []string{"Novel"},
+		Source:  "talebook",
+		ID:      7,
+	}}
+	assert.NoError(t, h.Run(context.Background(), book))
+	assert.Equal(t, `title:"=Emma \"Woodhouse\"" and authors:"=Jane Austen"`, stub.queries[len(stub.queries)-1])
+	assert.Equal(t, []byte("emma"), stub.books["/calibre/cdb/add-book/7/n/Emma.epub/Books"])
+	assert.Equal(t, map[string]any{
+		"title":       `Emma "Woodhouse"`,
+		"authors":     []any{"Jane Austen"},
+		"tags":        []any{"Novel"},
+		"identifiers": map[string]any{"talebook": "7"},
+	}, stub.fields["/calibre/cdb/set-fields/2/Books"])
+}
+
+func TestCalibreHook_DigestAuth(t *testing.T) {
+	var lengths []int64
+	var books [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="calibre", nonce="abc", qop="auth", algorithm="MD5"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		params := digestParams(strings.TrimPrefix(auth, "Digest "))
+		md5Hex := func(v string) string {
+			sum := md5.Sum([]byte(v))
+			return hex.EncodeToString(sum[:])
+		}
+		ha1 := md5Hex("admin:calibre:secret")
+		ha2 := md5Hex(r.Method + ":" + r.URL.RequestURI())
+		expected := md5Hex(strings.Join([]string{ha1, "abc", params["nc"], params["cnonce"], "auth", ha2}, ":"))
+		if params["uri"] != r.URL.RequestURI() || params["response"] != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/ajax/search":
+			_ = json.NewEncoder(w).Encode(map[string]any{"book_ids": []int{}})
+		case strings.HasPrefix(r.URL.Path, "/cdb/add-book/"):
+			lengths = append(lengths, r.ContentLength)
+			books = append(books, content)
+			_ = json.NewEncoder(w).Encode(map[string]any{"book_id": 2})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{})
+		}
+	}))
+	defer server.Close()
+
+	h, err := newCalibreHook(strings.Replace(server.URL, "http://", "http://admin:secret@", 1))
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "Emma.epub")
+	assert.NoError(t, os.WriteFile(path, []byte("emma"), 0o644))
+
+	// The streamed file is sent again with its length after the digest challenge.
+	book := &Book{ID: 7, Format: file.EPUB, Path: path, Metadata: &file.Metadata{Title: "Emma"}}
+	assert.NoError(t, h.Run(context.Background(), book))
+	assert.Equal(t, [][]byte{[]byte("emma")}, books)
+	assert.Equal(t, []int64{4}, lengths)
+}
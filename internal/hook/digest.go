@@ -0,0 +1,157 @@
+package hook
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// digestTransport signs the requests by the digest auth of RFC 7616 after the server responds the challenge.
+// It replaces the digest auth of resty, which is only applied to the requests sent by resty and always reads
+// the request body into memory.
+type digestTransport struct {
+	username  string
+	password  string
+	transport http.RoundTripper
+	count     atomic.Uint32
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(strings.ToLower(challenge), "digest ") {
+		return resp, nil
+	}
+	// The body has been sent, it should be created again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	auth, err := t.authorize(req, challenge)
+	if err != nil {
+		return resp, err
+	}
+	_ = resp.Body.Close()
+
+	signed := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if signed.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	signed.Header.Set("Authorization", auth)
+
+	return t.transport.RoundTrip(signed)
+}
+
+// authorize creates the authorization header by the challenge, only the auth qop is supported.
+func (t *digestTransport) authorize(req *http.Request, challenge string) (string, error) {
+	params := digestParams(challenge[len("digest "):])
+
+	var newHash func() hash.Hash
+	algorithm := params["algorithm"]
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %s", algorithm)
+	}
+	digest := func(values ...string) string {
+		h := newHash()
+		_, _ = h.Write([]byte(strings.Join(values, ":")))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	uri := req.URL.RequestURI()
+	ha1 := digest(t.username, params["realm"], t.password)
+	ha2 := digest(req.Method, uri)
+
+	fields := []string{
+		fmt.Sprintf("username=%q", t.username),
+		fmt.Sprintf("realm=%q", params["realm"]),
+		fmt.Sprintf("nonce=%q", params["nonce"]),
+		fmt.Sprintf("uri=%q", uri),
+	}
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+algorithm)
+	}
+
+	qop := params["qop"]
+	switch {
+	case qop == "":
+		fields = append(fields, fmt.Sprintf("response=%q", digest(ha1, params["nonce"], ha2)))
+	case containsToken(qop, "auth"):
+		cnonce := make([]byte, 8)
+		if _, err := rand.Read(cnonce); err != nil {
+			return "", err
+		}
+		nc := fmt.Sprintf("%08x", t.count.Add(1))
+		c := hex.EncodeToString(cnonce)
+		fields = append(fields,
+			fmt.Sprintf("response=%q", digest(ha1, params["nonce"], nc, c, "auth", ha2)),
+			"qop=auth", "nc="+nc, fmt.Sprintf("cnonce=%q", c))
+	default:
+		return "", errors.New("unsupported digest qop " + qop)
+	}
+
+	if opaque, ok := params["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf("opaque=%q", opaque))
+	}
+
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+// digestParams parses the key value pairs of the challenge, the values could be quoted.
+func digestParams(challenge string) map[string]string {
+	params := map[string]string{}
+	for challenge != "" {
+		key, rest, ok := strings.Cut(challenge, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value, rest = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+			rest = "," + rest
+		}
+		params[key] = strings.TrimSpace(value)
+
+		_, challenge, _ = strings.Cut(rest, ",")
+	}
+	return params
+}
+
+// containsToken checks if the comma separated list has the token.
+func containsToken(list, token string) bool {
+	for _, v := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package hook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestParams(t *testing.T) {
+	params := digestParams(`realm="calibre, \"books\"", nonce="abc", qop="auth,auth-int", algorithm=MD5, stale=false`)
+	assert.Equal(t, map[string]string{
+		"realm":     `calibre, "books"`,
+		"nonce":     "abc",
+		"qop":       "auth,auth-int",
+		"algorithm": "MD5",
+		"stale":     "false",
+	}, params)
+	assert.True(t, containsToken(params["qop"], "auth"))
+	assert.False(t, containsToken(params["qop"], "auth-conf"))
+}
@@ -0,0 +1,82 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+// maxOutputLength is the max length of the command output in the error message.
+const maxOutputLength = 512
+
+// execHook runs the external command with the book information in the environment variables.
+type execHook struct {
+	command []string
+}
+
+func newExecHook(arg string) (Hook, error) {
+	command := strings.Fields(arg)
+	if len(command) == 0 {
+		return nil, errors.New("the command is required, such as exec:/path/to/script.sh")
+	}
+	return &execHook{command: command}, nil
+}
+
+func (e *execHook) Run(ctx context.Context, book *Book) error {
+	cmd := exec.CommandContext(ctx, e.command[0], e.command[1:]...)
+	cmd.Env = append(os.Environ(), environments(book)...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if o := strings.TrimSpace(string(output)); o != "" {
+			if len(o) > maxOutputLength {
+				o = o[len(o)-maxOutputLength:]
+			}
+			return fmt.Errorf("%w: %s", err, o)
+		}
+		return err
+	}
+	log.Debugf("The output of %s: %s", e.command[0], output)
+
+	return nil
+}
+
+// environments exposes the book as the BOOKHUNTER_ prefixed environment variables.
+func environments(book *Book) []string {
+	env := map[string]string{
+		"FILE":   book.Path,
+		"FORMAT": string(book.Format),
+		"ID":     strconv.FormatInt(book.ID, 10),
+		"SIZE":   strconv.FormatInt(book.Size, 10),
+		"SHA256": book.SHA256,
+	}
+	if m := book.Metadata; m != nil {
+		env["TITLE"] = m.Title
+		env["AUTHORS"] = strings.Join(m.Authors, ", ")
+		env["PUBLISHER"] = m.Publisher
+		env["TAGS"] = strings.Join(m.Tags, ", ")
+		env["SERIES"] = m.Series
+		env["SOURCE"] = m.Source
+		env["ISBN"] = m.ISBN
+		if !m.Published.IsZero() {
+			env["PUBLISHED"] = m.Published.Format(time.DateOnly)
+		}
+		if content, err := json.Marshal(m); err == nil {
+			env["METADATA"] = string(content)
+		}
+	}
+
+	var vars []string
+	for key, value := range env {
+		vars = append(vars, "BOOKHUNTER_"+key+"="+value)
+	}
+	return vars
+}
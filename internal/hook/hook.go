@@ -0,0 +1,104 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+// Book is the downloaded file which is processed by the hooks.
+type Book struct {
+	ID       int64
+	Format   file.Format
+	Root     string // Root is the download directory.
+	Path     string // Path is the file path, it will be changed if the hook moves the file.
+	Size     int64
+	SHA256   string
+	Metadata *file.Metadata
+}
+
+// Hook is a post-processing step for the downloaded file.
+type Hook interface {
+	Run(ctx context.Context, book *Book) error
+}
+
+// Factory creates the hook by the argument after the colon in the hook definition, such as the directory in move:/books.
+type Factory func(arg string) (Hook, error)
+
+var (
+	factories = map[string]Factory{}
+	lock      sync.RWMutex
+)
+
+// Register will add a named hook which could be used in the --hook flag. The existing hook will be replaced.
+func Register(name string, factory Factory) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	factories[name] = factory
+}
+
+func init() {
+	Register("calibre", newCalibreHook)
+	Register("exec", newExecHook)
+	Register("move", newMoveHook)
+}
+
+// Pipeline is the hooks which run one by one for every downloaded file.
+type Pipeline []step
+
+type step struct {
+	name string
+	hook Hook
+}
+
+// Parse will create the pipeline by the definitions, such as exec:/path/to/script.sh, move:/books and the registered names.
+func Parse(definitions []string) (Pipeline, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var pipeline Pipeline
+	for _, definition := range definitions {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		name, arg, _ := strings.Cut(definition, ":")
+		factory, ok := factories[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid hook %s, it should be one of %s", definition, strings.Join(names(), ", "))
+		}
+		h, err := factory(arg)
+		if err != nil {
+			// The definition isn't printed for hiding the credentials in the arguments.
+			return nil, fmt.Errorf("invalid hook %s: %w", name, err)
+		}
+		pipeline = append(pipeline, step{name: name, hook: h})
+	}
+
+	return pipeline, nil
+}
+
+func names() []string {
+	var ns []string
+	for name := range factories {
+		ns = append(ns, name)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+// Run will process the book by the hooks in order. The remaining hooks are skipped if a hook failed.
+func (p Pipeline) Run(ctx context.Context, book *Book) error {
+	for _, s := range p {
+		if err := s.hook.Run(ctx, book); err != nil {
+			return fmt.Errorf("%s hook failed on %s: %w", s.name, book.Path, err)
+		}
+	}
+	return nil
+}
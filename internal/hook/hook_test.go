@@ -0,0 +1,91 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+type recordHook struct {
+	paths *[]string
+	err   error
+}
+
+func (r *recordHook) Run(_ context.Context, book *Book) error {
+	*r.paths = append(*r.paths, book.Path)
+	return r.err
+}
+
+func TestParse(t *testing.T) {
+	for _, definition := range []string{"unknown", "exec:", "move:"} {
+		_, err := Parse([]string{definition})
+		assert.Error(t, err, definition)
+	}
+
+	pipeline, err := Parse([]string{"exec:echo hello", " ", "move:/books"})
+	assert.NoError(t, err)
+	assert.Len(t, pipeline, 2)
+}
+
+func TestPipeline_Run(t *testing.T) {
+	var paths []string
+	Register("record", func(arg string) (Hook, error) {
+		if arg == "fail" {
+			return &recordHook{paths: &paths, err: errors.New("broken")}, nil
+		}
+		return &recordHook{paths: &paths}, nil
+	})
+
+	root, library := t.TempDir(), t.TempDir()
+	book := filepath.Join(root, "Austen", "Emma.epub")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(book), 0o755))
+	assert.NoError(t, os.WriteFile(book, []byte("emma"), 0o644))
+	assert.NoError(t, os.WriteFile(file.SidecarPath(book, ".opf"), []byte("opf"), 0o644))
+
+	pipeline, err := Parse([]string{"record", "move:" + library, "record:fail", "record"})
+	assert.NoError(t, err)
+
+	b := &Book{ID: 1, Format: file.EPUB, Root: root, Path: book}
+	assert.ErrorContains(t, pipeline.Run(context.Background(), b), "record hook failed")
+
+	// The file is moved with the sidecar and the hooks after the failed one are skipped.
+	target := filepath.Join(library, "Austen", "Emma.epub")
+	assert.Equal(t, []string{book, target}, paths)
+	assert.Equal(t, target, b.Path)
+	_, err = os.Stat(file.SidecarPath(target, ".opf"))
+	assert.NoError(t, err)
+	_, err = os.Stat(book)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestExecHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the shell script can't be executed on windows")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	output := filepath.Join(dir, "output.txt")
+	content := "#!/bin/sh\necho \"$BOOKHUNTER_ID $BOOKHUNTER_TITLE $BOOKHUNTER_FILE $1\" > " + output + "\n" +
+		"[ \"$BOOKHUNTER_FORMAT\" = epub ] || { echo \"unexpected format\"; exit 3; }\n"
+	assert.NoError(t, os.WriteFile(script, []byte(content), 0o755))
+
+	h, err := newExecHook(script + " argument")
+	assert.NoError(t, err)
+	book := &Book{ID: 7, Format: file.EPUB, Path: "/books/emma.epub", Metadata: &file.Metadata{Title: "Emma"}}
+	assert.NoError(t, h.Run(context.Background(), book))
+	result, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "7 Emma /books/emma.epub argument\n", string(result))
+
+	// The command output is included in the error.
+	book.Format = file.PDF
+	assert.ErrorContains(t, h.Run(context.Background(), book), "unexpected format")
+}
@@ -0,0 +1,92 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bookstairs/bookhunter/internal/file"
+)
+
+// sidecarExtensions are the metadata and cover files which are moved with the book.
+var sidecarExtensions = []string{".opf", ".json", ".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
+// moveHook moves the book into the library directory, the sub directories in the download directory are kept.
+type moveHook struct {
+	library string
+}
+
+func newMoveHook(arg string) (Hook, error) {
+	if strings.TrimSpace(arg) == "" {
+		return nil, errors.New("the library directory is required, such as move:/books")
+	}
+	library, err := filepath.Abs(arg)
+	if err != nil {
+		return nil, err
+	}
+	return &moveHook{library: library}, nil
+}
+
+func (m *moveHook) Run(_ context.Context, book *Book) error {
+	name, err := filepath.Rel(book.Root, book.Path)
+	if err != nil || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		name = filepath.Base(book.Path)
+	}
+	target := file.UniquePath(filepath.Join(m.library, name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := moveFile(book.Path, target); err != nil {
+		return err
+	}
+	source := book.Path
+	book.Path = target
+
+	// Move the sidecars with the same name of the book.
+	for _, ext := range sidecarExtensions {
+		sidecar := file.SidecarPath(source, ext)
+		if sidecar == source {
+			continue
+		}
+		if _, err := os.Stat(sidecar); err == nil {
+			if err := moveFile(sidecar, file.SidecarPath(target, ext)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// moveFile will rename the file, or copy it if the target is on a different device.
+func moveFile(source, target string) error {
+	if err := os.Rename(source, target); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(target)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(target)
+		return err
+	}
+	_ = src.Close()
+
+	return os.Remove(source)
+}
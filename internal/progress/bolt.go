@@ -0,0 +1,280 @@
+package progress
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bits-and-blooms/bitset"
+	"go.etcd.io/bbolt"
+	"go.uber.org/ratelimit"
+
+	"github.com/bookstairs/bookhunter/internal/log"
+)
+
+var booksBucket = []byte("books")
+
+// boltProgress is an embedded database implementation which records the detailed state of every book.
+type boltProgress struct {
+	limit    ratelimit.Limiter // The ratelimit for acquiring a book ID.
+	db       *bbolt.DB         // The database for persisting the book records.
+	size     int64             // The total book size.
+	finished *bitset.BitSet    // The finished books, memory based.
+	assigned *bitset.BitSet    // The assign status, memory based.
+	lock     *sync.Mutex       // lock is used for concurrent request.
+}
+
+// NewBoltProgress will create the progress in the bolt database. The legacy bitset progress file will be
+// imported if the database doesn't exist.
+func NewBoltProgress(start, size int64, rate int, path, legacy string) (Progress, error) {
+	if start < 1 {
+		return nil, ErrStartBookID
+	}
+	if start > size {
+		return nil, ErrStartAndEndBookID
+	}
+
+	_, err := os.Stat(path)
+	migrate := errors.Is(err, os.ErrNotExist)
+
+	db, err := bbolt.Open(path, 0o644, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(booksBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	if migrate {
+		count, err := importBitset(db, legacy)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		if count > 0 {
+			log.Infof("Imported %d finished books from the progress file %s", count, legacy)
+		}
+	}
+
+	finished := bitset.New(uint(size))
+	assigned := bitset.New(uint(size))
+	for i := uint(0); i < uint(start-1); i++ {
+		finished.Set(i)
+		assigned.Set(i)
+	}
+
+	// Load the book records.
+	err = db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(booksBucket).ForEach(func(_, v []byte) error {
+			record := new(Record)
+			if err := json.Unmarshal(v, record); err != nil {
+				return err
+			}
+			if record.BookID > size {
+				return nil
+			}
+
+			i := uint(record.BookID - 1)
+			if record.Finished() {
+				finished.Set(i)
+			}
+			if record.Status != Pending {
+				assigned.Set(i)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &boltProgress{
+		limit:    ratelimit.New(rate, ratelimit.Per(time.Minute)),
+		db:       db,
+		size:     size,
+		finished: finished,
+		assigned: assigned,
+		lock:     new(sync.Mutex),
+	}, nil
+}
+
+// importBitset will save the finished books in the bitset file as the done records,
+// and the failed books in the file with the .failed suffix as the failed records.
+func importBitset(db *bbolt.DB, path string) (int, error) {
+	failed, err := loadFailed(path + failedSuffix)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	set, err := loadStorage(file)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(booksBucket)
+		now := time.Now()
+		for i, ok := set.NextSet(0); ok; i, ok = set.NextSet(i + 1) {
+			id := int64(i + 1)
+			data, err := json.Marshal(&Record{BookID: id, Status: Done, Time: now})
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(bookKey(id), data); err != nil {
+				return err
+			}
+			delete(failed, id)
+			count++
+		}
+		for id, record := range failed {
+			data, err := json.Marshal(&record)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(bookKey(id), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+func bookKey(bookID int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(bookID))
+	return key
+}
+
+// TakeRateLimit block until the rate meets the given config.
+func (storage *boltProgress) TakeRateLimit() {
+	storage.limit.Take()
+}
+
+// AcquireBookID would find the book id from the assign array.
+func (storage *boltProgress) AcquireBookID() int64 {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	if i, ok := storage.assigned.NextClear(0); ok && i < uint(storage.size) {
+		storage.assigned.Set(i)
+		return int64(i + 1)
+	}
+
+	return NoBookToDownload
+}
+
+// SaveBookID would save the download progress.
+func (storage *boltProgress) SaveBookID(bookID int64) error {
+	return storage.SaveRecord(&Record{BookID: bookID, Status: Done})
+}
+
+// SaveRecord would save the book state, the attempts will be accumulated with the saved record.
+func (storage *boltProgress) SaveRecord(record *Record) error {
+	if record.BookID < 1 || record.BookID > storage.size {
+		return fmt.Errorf("invalid book id: %d", record.BookID)
+	}
+
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	r := *record
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	err := storage.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(booksBucket)
+		key := bookKey(r.BookID)
+		if v := bucket.Get(key); v != nil {
+			saved := new(Record)
+			if err := json.Unmarshal(v, saved); err == nil {
+				r.Attempts += saved.Attempts
+			}
+		}
+
+		data, err := json.Marshal(&r)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	i := uint(r.BookID - 1)
+	storage.assigned.Set(i)
+	if r.Finished() {
+		storage.finished.Set(i)
+	} else {
+		storage.finished.Clear(i)
+	}
+
+	return nil
+}
+
+// RetryFailed would make only the failed books available for acquiring.
+func (storage *boltProgress) RetryFailed() ([]Record, error) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	var records []Record
+	err := storage.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(booksBucket).ForEach(func(_, v []byte) error {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if record.Status == Failed && record.BookID <= storage.size {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storage.assigned.SetAll()
+	for i := range records {
+		storage.assigned.Clear(uint(records[i].BookID - 1))
+	}
+
+	return records, nil
+}
+
+// Finished would tell the called whether all the books have downloaded.
+func (storage *boltProgress) Finished() bool {
+	return storage.finished.Count() == uint(storage.size)
+}
+
+// Size would return the book size.
+func (storage *boltProgress) Size() int64 {
+	return storage.size
+}
+
+// Close would close the database, all the records have been persisted in SaveRecord.
+func (storage *boltProgress) Close() error {
+	return storage.db.Close()
+}
@@ -0,0 +1,117 @@
+package progress
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltProgress_ImportBitset(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "progress.db")
+
+	// Finish the first 10 books in the bitset progress.
+	s, err := NewProgress(1, 100, 1000000, legacy)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, s.SaveBookID(s.AcquireBookID()))
+	}
+	assert.NoError(t, s.Close())
+
+	p, err := New(BoltStorage, 1, 100, 1000000, legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), p.AcquireBookID())
+
+	assert.NoError(t, p.SaveRecord(&Record{BookID: 11, Status: Failed, Attempts: 2, LastError: "timeout"}))
+	assert.NoError(t, p.SaveRecord(&Record{BookID: 11, Status: Failed, Attempts: 1, LastError: "timeout"}))
+	assert.NoError(t, p.Close())
+
+	// The failed book shouldn't be acquired again and the legacy progress shouldn't be imported twice.
+	p, err = New(BoltStorage, 1, 120, 1000000, legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), p.AcquireBookID())
+	assert.Equal(t, int64(120), p.Size())
+	assert.False(t, p.Finished())
+	assert.NoError(t, p.Close())
+}
+
+func TestBoltProgress_RetryFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.db")
+
+	p, err := New(BoltStorage, 1, 10, 1000000, path)
+	assert.NoError(t, err)
+	for i := int64(1); i <= 10; i++ {
+		assert.Equal(t, i, p.AcquireBookID())
+		record := &Record{BookID: i, Status: Done, Attempts: 1}
+		if i%4 == 0 {
+			record.Status = Failed
+			record.LastError = "connection reset"
+		}
+		assert.NoError(t, p.SaveRecord(record))
+	}
+	assert.False(t, p.Finished())
+	assert.NoError(t, p.Close())
+
+	p, err = New(BoltStorage, 1, 10, 1000000, path)
+	assert.NoError(t, err)
+	records, err := p.RetryFailed()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "connection reset", records[0].LastError)
+
+	// Only the failed books could be acquired.
+	assert.Equal(t, int64(4), p.AcquireBookID())
+	assert.Equal(t, int64(8), p.AcquireBookID())
+	assert.Equal(t, int64(NoBookToDownload), p.AcquireBookID())
+
+	assert.NoError(t, p.SaveRecord(&Record{BookID: 4, Status: Done, Attempts: 1}))
+	assert.NoError(t, p.SaveRecord(&Record{BookID: 8, Status: Done, Attempts: 1}))
+	assert.True(t, p.Finished())
+	assert.NoError(t, p.Close())
+}
+
+func TestBitProgress_RetryFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.db")
+
+	p, err := New(BitsetStorage, 1, 10, 1000000, path)
+	assert.NoError(t, err)
+	for i := int64(1); i <= 10; i++ {
+		assert.Equal(t, i, p.AcquireBookID())
+		record := &Record{BookID: i, Status: Done, Attempts: 1}
+		if i%4 == 0 {
+			record.Status = Failed
+			record.LastError = "connection reset"
+		}
+		assert.NoError(t, p.SaveRecord(record))
+	}
+	assert.NoError(t, p.SaveRecord(&Record{BookID: 8, Status: Failed, Attempts: 2, LastError: "timeout"}))
+	assert.NoError(t, p.Close())
+
+	p, err = New(BitsetStorage, 1, 10, 1000000, path)
+	assert.NoError(t, err)
+	records, err := p.RetryFailed()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, int64(8), records[1].BookID)
+		assert.Equal(t, 3, records[1].Attempts)
+		assert.Equal(t, "timeout", records[1].LastError)
+	}
+
+	// Only the failed books could be acquired.
+	assert.Equal(t, int64(4), p.AcquireBookID())
+	assert.Equal(t, int64(8), p.AcquireBookID())
+	assert.Equal(t, int64(NoBookToDownload), p.AcquireBookID())
+	assert.NoError(t, p.SaveRecord(&Record{BookID: 4, Status: Done, Attempts: 1}))
+	assert.NoError(t, p.Close())
+
+	// The finished book isn't retried again, the failed book is imported by the bolt storage.
+	p, err = New(BoltStorage, 1, 10, 1000000, path)
+	assert.NoError(t, err)
+	records, err = p.RetryFailed()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, int64(8), records[0].BookID)
+	}
+	assert.NoError(t, p.Close())
+}
@@ -0,0 +1,201 @@
+package progress
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/ratelimit"
+)
+
+var (
+	ErrInvalidBookIDs = errors.New("the book ids should be positive numbers or ranges like 900-1200")
+	ErrTooManyBookIDs = fmt.Errorf("the book ids couldn't be more than %d, use --initial and --end for the large ranges", maxBookIDs)
+)
+
+// maxBookIDs is the max number of the given book ids, they are all kept in memory.
+const maxBookIDs = 1_000_000
+
+// ParseBookIDs will parse the book ids in the form of 12,55,900-1200. The returned ids are sorted without duplication.
+func ParseBookIDs(values ...string) ([]int64, error) {
+	set := map[int64]struct{}{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+
+			start, end, isRange := strings.Cut(item, "-")
+			first, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+			if err != nil || first < 1 {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidBookIDs, item)
+			}
+			last := first
+			if isRange {
+				if last, err = strconv.ParseInt(strings.TrimSpace(end), 10, 64); err != nil || last < first {
+					return nil, fmt.Errorf("%w: %s", ErrInvalidBookIDs, item)
+				}
+			}
+
+			if last-first >= maxBookIDs-int64(len(set)) {
+				return nil, fmt.Errorf("%w: %s", ErrTooManyBookIDs, item)
+			}
+			for id := first; id <= last; id++ {
+				set[id] = struct{}{}
+			}
+		}
+	}
+
+	ids := make([]int64, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
+
+// ReadBookIDs will load the book ids from the file. Every line could contain the ids in the ParseBookIDs form,
+// the empty lines and the lines start with # will be ignored.
+func ReadBookIDs(path string) ([]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ParseBookIDs(lines...)
+}
+
+// listProgress only downloads the given book ids, the progress is kept in memory.
+type listProgress struct {
+	limit    ratelimit.Limiter // The ratelimit for acquiring a book ID.
+	ids      []int64           // The books to download.
+	next     int               // The index of the next book to acquire.
+	finished map[int64]bool    // The finished books.
+	size     int64             // The total book size.
+	lock     *sync.Mutex       // lock is used for concurrent request.
+}
+
+// NewListProgress will create a progress for the given book ids without touching the progress file.
+// The ids larger than the book size will be ignored.
+func NewListProgress(ids []int64, size int64, rate int) (Progress, error) {
+	var valid []int64
+	for _, id := range ids {
+		if id >= 1 && id <= size {
+			valid = append(valid, id)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, ErrStartAndEndBookID
+	}
+
+	return &listProgress{
+		limit:    ratelimit.New(rate, ratelimit.Per(time.Minute)),
+		ids:      valid,
+		finished: map[int64]bool{},
+		size:     size,
+		lock:     new(sync.Mutex),
+	}, nil
+}
+
+// TakeRateLimit block until the rate meets the given config.
+func (storage *listProgress) TakeRateLimit() {
+	storage.limit.Take()
+}
+
+// AcquireBookID would return the next book id in the list.
+func (storage *listProgress) AcquireBookID() int64 {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	if storage.next >= len(storage.ids) {
+		return NoBookToDownload
+	}
+	id := storage.ids[storage.next]
+	storage.next++
+
+	return id
+}
+
+// SaveBookID would save the download progress.
+func (storage *listProgress) SaveBookID(bookID int64) error {
+	return storage.SaveRecord(&Record{BookID: bookID, Status: Done})
+}
+
+// SaveRecord would save the book state in memory.
+func (storage *listProgress) SaveRecord(record *Record) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	storage.finished[record.BookID] = record.Finished()
+	return nil
+}
+
+// RetryFailed isn't supported, the failed books could be downloaded by using the same ids.
+func (storage *listProgress) RetryFailed() ([]Record, error) {
+	return nil, ErrRetryUnsupported
+}
+
+// Finished would tell the called whether all the given books have downloaded.
+func (storage *listProgress) Finished() bool {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	for _, id := range storage.ids {
+		if !storage.finished[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// Size would return the book size.
+func (storage *listProgress) Size() int64 {
+	return storage.size
+}
+
+// Close does nothing, the list progress isn't persisted.
+func (storage *listProgress) Close() error {
+	return nil
+}
+
+// boundedProgress stops acquiring the book ids which are larger than the end book id.
+type boundedProgress struct {
+	Progress
+	end int64 // The last book id to download.
+}
+
+// WithEndBookID will limit the download progress to the given end book id.
+func WithEndBookID(progress Progress, end int64) Progress {
+	if end <= 0 || end >= progress.Size() {
+		return progress
+	}
+	return &boundedProgress{Progress: progress, end: end}
+}
+
+// AcquireBookID would find the book id from the wrapped progress until it exceeds the end book id.
+func (storage *boundedProgress) AcquireBookID() int64 {
+	if id := storage.Progress.AcquireBookID(); id != NoBookToDownload && id <= storage.end {
+		return id
+	}
+	return NoBookToDownload
+}
@@ -0,0 +1,62 @@
+package progress
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBookIDs(t *testing.T) {
+	ids, err := ParseBookIDs("12,55,900-903", "55", " 7 ")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{7, 12, 55, 900, 901, 902, 903}, ids)
+
+	for _, invalid := range []string{"0", "abc", "12-3", "5-", "-5"} {
+		_, err = ParseBookIDs(invalid)
+		assert.ErrorIs(t, err, ErrInvalidBookIDs, invalid)
+	}
+
+	// The huge ranges are rejected without allocating all the ids.
+	for _, huge := range []string{"1-9223372036854775807", "1-999999,2000000-2000001"} {
+		_, err = ParseBookIDs(huge)
+		assert.ErrorIs(t, err, ErrTooManyBookIDs, huge)
+	}
+}
+
+func TestReadBookIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# The wanted books\n3\n\n10-12,1\n"), 0o644))
+
+	ids, err := ReadBookIDs(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3, 10, 11, 12}, ids)
+}
+
+func TestListProgress(t *testing.T) {
+	p, err := NewListProgress([]int64{3, 8, 200}, 100, 1000000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), p.AcquireBookID())
+	assert.Equal(t, int64(8), p.AcquireBookID())
+	assert.Equal(t, int64(NoBookToDownload), p.AcquireBookID())
+
+	assert.NoError(t, p.SaveBookID(3))
+	assert.False(t, p.Finished())
+	assert.NoError(t, p.SaveRecord(&Record{BookID: 8, Status: Skipped}))
+	assert.True(t, p.Finished())
+	assert.Equal(t, int64(100), p.Size())
+
+	_, err = NewListProgress([]int64{200}, 100, 1000000)
+	assert.Error(t, err)
+}
+
+func TestWithEndBookID(t *testing.T) {
+	s, err := NewProgress(5, 100, 1000000, filepath.Join(t.TempDir(), "progress.db"))
+	assert.NoError(t, err)
+	p := WithEndBookID(s, 6)
+	assert.Equal(t, int64(5), p.AcquireBookID())
+	assert.Equal(t, int64(6), p.AcquireBookID())
+	assert.Equal(t, int64(NoBookToDownload), p.AcquireBookID())
+	assert.NoError(t, p.Close())
+}
@@ -1,9 +1,12 @@
 package progress
 
 import (
+	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,12 +14,16 @@ import (
 	"go.uber.org/ratelimit"
 )
 
-const NoBookToDownload = -1
+const (
+	NoBookToDownload = -1
+	failedSuffix     = ".failed" // The suffix of the file which records the failed books for the bitset progress.
+)
 
 var (
 	ErrStartBookID       = errors.New("the start book id should start from 1")
 	ErrStartAndEndBookID = errors.New("start book id should below the available book id")
 	ErrStorageFile       = errors.New("couldn't create file for storing download process")
+	ErrRetryUnsupported  = errors.New("the failed books aren't recorded for the given book ids")
 )
 
 type Progress interface {
@@ -29,11 +36,20 @@ type Progress interface {
 	// SaveBookID would save the download progress.
 	SaveBookID(bookID int64) error
 
+	// SaveRecord would save the download state of the book.
+	SaveRecord(record *Record) error
+
+	// RetryFailed would make only the failed books available for acquiring, it returns the failed books.
+	RetryFailed() ([]Record, error)
+
 	// Finished would tell the called whether all the books have downloaded.
 	Finished() bool
 
 	// Size would return the book size.
 	Size() int64
+
+	// Close would flush the download progress and release the underlying storage.
+	Close() error
 }
 
 // bitProgress is a bit-based implementation with file persistence.
@@ -43,6 +59,8 @@ type bitProgress struct {
 	assigned *bitset.BitSet    // the assign status, memory based.
 	lock     *sync.Mutex       // lock is used for concurrent request.
 	file     *os.File          // The Progress file path for download progress.
+	failed   map[int64]Record  // The failed books which are recorded in the file with the .failed suffix.
+	failures string            // The file path for the failed books.
 }
 
 // NewProgress Create a storage for save the download progress.
@@ -99,6 +117,12 @@ func NewProgress(start, size int64, rate int, path string) (Progress, error) {
 	assigned := bitset.New(progress.Len())
 	progress.Copy(assigned)
 
+	failed, err := loadFailed(path + failedSuffix)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
 	// Create ratelimit
 	limit := ratelimit.New(rate, ratelimit.Per(time.Minute))
 
@@ -108,6 +132,8 @@ func NewProgress(start, size int64, rate int, path string) (Progress, error) {
 		assigned: assigned,
 		lock:     new(sync.Mutex),
 		file:     file,
+		failed:   failed,
+		failures: path + failedSuffix,
 	}, nil
 }
 
@@ -130,6 +156,31 @@ func loadStorage(file *os.File) (*bitset.BitSet, error) {
 	return set, nil
 }
 
+// loadFailed will read the failed records, the attempts of the same book are accumulated.
+func loadFailed(path string) (map[int64]Record, error) {
+	failed := map[int64]Record{}
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return failed, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		record.Attempts += failed[record.BookID].Attempts
+		failed[record.BookID] = record
+	}
+
+	return failed, scanner.Err()
+}
+
 // TakeRateLimit block until the rate meets the given config.
 func (storage *bitProgress) TakeRateLimit() {
 	storage.limit.Take()
@@ -168,6 +219,64 @@ func (storage *bitProgress) SaveBookID(bookID int64) error {
 	return nil
 }
 
+// RetryFailed would make only the failed books which haven't been finished available for acquiring.
+func (storage *bitProgress) RetryFailed() ([]Record, error) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	var records []Record
+	for id, record := range storage.failed {
+		if id <= int64(storage.progress.Len()) && !storage.progress.Test(uint(id-1)) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].BookID < records[j].BookID })
+
+	storage.assigned.SetAll()
+	for i := range records {
+		storage.assigned.Clear(uint(records[i].BookID - 1))
+	}
+
+	return records, nil
+}
+
+// SaveRecord would persist the finished books in the bitset, the failed books are appended into the file
+// with the .failed suffix for retrying them.
+func (storage *bitProgress) SaveRecord(record *Record) error {
+	if record.Finished() {
+		return storage.SaveBookID(record.BookID)
+	}
+	if record.Status != Failed {
+		return nil
+	}
+
+	r := *record
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	line, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	file, err := os.OpenFile(storage.failures, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	r.Attempts += storage.failed[r.BookID].Attempts
+	storage.failed[r.BookID] = r
+
+	return nil
+}
+
 // Finished would tell the called whether all the books have downloaded.
 func (storage *bitProgress) Finished() bool {
 	return storage.progress.Count() == storage.progress.Len()
@@ -177,3 +286,18 @@ func (storage *bitProgress) Finished() bool {
 func (storage *bitProgress) Size() int64 {
 	return int64(storage.progress.Len())
 }
+
+// Close would flush the download progress into the file and close it.
+func (storage *bitProgress) Close() error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	if err := saveStorage(storage.file, storage.progress); err != nil {
+		return err
+	}
+	if err := storage.file.Sync(); err != nil {
+		return err
+	}
+
+	return storage.file.Close()
+}
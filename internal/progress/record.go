@@ -0,0 +1,64 @@
+package progress
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Storage string // The storage backend for persisting the download progress.
+
+const (
+	BitsetStorage Storage = "bitset" // BitsetStorage only records the finished books in a bitset file.
+	BoltStorage   Storage = "bolt"   // BoltStorage records the detailed state of every book in an embedded database.
+)
+
+// ParseStorage will create the storage from the string.
+func ParseStorage(storage string) (Storage, error) {
+	switch s := Storage(strings.ToLower(storage)); s {
+	case BitsetStorage, BoltStorage:
+		return s, nil
+	case "":
+		return BitsetStorage, nil
+	default:
+		return "", fmt.Errorf("invalid progress storage %s, it should be bitset or bolt", storage)
+	}
+}
+
+type Status string // The download status of a book.
+
+const (
+	Pending Status = "pending" // The book hasn't been downloaded.
+	Failed  Status = "failed"  // The book download has failed.
+	Skipped Status = "skipped" // The book has nothing to download.
+	Done    Status = "done"    // The book has been downloaded.
+)
+
+// Record is the download state of a book.
+type Record struct {
+	BookID    int64     `json:"id"`
+	Status    Status    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	Files     []string  `json:"files,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Finished means this book doesn't need to be downloaded again.
+func (r *Record) Finished() bool {
+	return r.Status == Done || r.Status == Skipped
+}
+
+// New will create the download progress by the given storage. The path is the bitset progress file,
+// the bolt storage will use the same file name with a .bolt extension and import the existing bitset progress.
+func New(storage Storage, start, size int64, rate int, path string) (Progress, error) {
+	switch storage {
+	case BitsetStorage, "":
+		return NewProgress(start, size, rate, path)
+	case BoltStorage:
+		return NewBoltProgress(start, size, rate, strings.TrimSuffix(path, filepath.Ext(path))+".bolt", path)
+	default:
+		return nil, fmt.Errorf("invalid progress storage %s", storage)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 
 	"github.com/bookstairs/bookhunter/internal/driver"
+	"github.com/bookstairs/bookhunter/internal/file"
 	"github.com/bookstairs/bookhunter/internal/log"
 )
 
@@ -79,3 +81,41 @@ func ParseLinks(content string, id int64) (title string, links map[driver.Source
 	}
 	return title, links, nil
 }
+
+// ParseMetadata will find the book information in the book page, the missing fields are left empty.
+func ParseMetadata(content string) (*file.Metadata, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &file.Metadata{Title: strings.TrimSpace(doc.Find(".article-title>a").Text())}
+	metadata.Cover, _ = doc.Find(".bookpic img").Attr("src")
+	doc.Find("div.bookinfo > ul > li").Each(func(_ int, li *goquery.Selection) {
+		label := strings.TrimSpace(li.Find("strong").Text())
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(li.Text()), label))
+		switch strings.TrimRight(label, "：:") {
+		case "作者":
+			for _, author := range strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == '、' }) {
+				if author = strings.TrimSpace(author); author != "" {
+					metadata.Authors = append(metadata.Authors, author)
+				}
+			}
+		case "标签":
+			li.Find("a").Each(func(_ int, a *goquery.Selection) {
+				if tag := strings.TrimSpace(a.Text()); tag != "" {
+					metadata.Tags = append(metadata.Tags, tag)
+				}
+			})
+		case "时间":
+			// The time when the book is shared, it's used as the published date for the date filters.
+			if date := dateRe.FindString(value); date != "" {
+				metadata.Published, _ = time.Parse(time.DateOnly, date)
+			}
+		case "ISBN":
+			metadata.ISBN = value
+		}
+	})
+
+	return metadata, nil
+}
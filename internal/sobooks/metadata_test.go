@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestParseSobooksUrl(t *testing.T) {
@@ -31,3 +33,27 @@ func TestParseSobooksUrl(t *testing.T) {
 	_, links, _ := ParseLinks(resp.String(), id)
 	fmt.Printf("%v", links)
 }
+
+func TestParseMetadata(t *testing.T) {
+	content := `<html><body>
+<h1 class="article-title"><a href="https://sobooks.cc/books/18021.html">三体</a></h1>
+<div class="bookpic"><img src="https://sobooks.cc/cover/18021.jpg" alt="三体"></div>
+<div class="bookinfo"><ul>
+<li><strong>书名：</strong>三体</li>
+<li><strong>作者：</strong>刘慈欣 / 某译者</li>
+<li><strong>浏览：</strong>12345</li>
+<li><strong>标签：</strong><a href="#">科幻</a> <a href="#">小说</a></li>
+<li><strong>时间：</strong>2021-05-20</li>
+<li><strong>ISBN：</strong>9787536692930</li>
+</ul></div>
+</body></html>`
+
+	metadata, err := ParseMetadata(content)
+	assert.NoError(t, err)
+	assert.Equal(t, "三体", metadata.Title)
+	assert.Equal(t, []string{"刘慈欣", "某译者"}, metadata.Authors)
+	assert.Equal(t, []string{"科幻", "小说"}, metadata.Tags)
+	assert.Equal(t, "9787536692930", metadata.ISBN)
+	assert.Equal(t, time.Date(2021, 5, 20, 0, 0, 0, 0, time.UTC), metadata.Published)
+	assert.Equal(t, "https://sobooks.cc/cover/18021.jpg", metadata.Cover)
+}
@@ -22,9 +22,18 @@ type BookResp struct {
 	Msg          string `json:"msg"`
 	KindleSender string `json:"kindle_sender"`
 	Book         struct {
-		ID    int    `json:"id"`
-		Title string `json:"title"`
-		Files []struct {
+		ID        int      `json:"id"`
+		Title     string   `json:"title"`
+		Authors   []string `json:"authors"`
+		Publisher string   `json:"publisher"`
+		Tags      []string `json:"tags"`
+		PubDate   string   `json:"pubdate"`
+		Series    string   `json:"series"`
+		ISBN      string   `json:"isbn"`
+		Comments  string   `json:"comments"`
+		Language  string   `json:"language"`
+		Img       string   `json:"img"`
+		Files     []struct {
 			Format string `json:"format"`
 			Size   int64  `json:"size"`
 			Href   string `json:"href"`
@@ -1,6 +1,7 @@
 package telegram
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -10,23 +11,23 @@ import (
 	"github.com/bookstairs/bookhunter/internal/log"
 )
 
-func (t *Telegram) ChannelInfo() (*ChannelInfo, error) {
+func (t *Telegram) ChannelInfo(ctx context.Context) (*ChannelInfo, error) {
 	var channelID int64
 	var accessHash int64
 	var err error
 
 	// Get the real channelID and accessHash.
 	if strings.HasPrefix(t.channelID, "joinchat/") {
-		channelID, accessHash, err = t.privateChannelInfo(strings.TrimPrefix(t.channelID, "joinchat/"))
+		channelID, accessHash, err = t.privateChannelInfo(ctx, strings.TrimPrefix(t.channelID, "joinchat/"))
 	} else {
-		channelID, accessHash, err = t.publicChannelInfo(t.channelID)
+		channelID, accessHash, err = t.publicChannelInfo(ctx, t.channelID)
 	}
 	if err != nil {
 		return nil, err
 	}
 
 	// Query the last message ID.
-	lastMsgID, err := t.queryLastMsgID(channelID, accessHash)
+	lastMsgID, err := t.queryLastMsgID(ctx, channelID, accessHash)
 	if err != nil {
 		return nil, err
 	}
@@ -39,8 +40,8 @@ func (t *Telegram) ChannelInfo() (*ChannelInfo, error) {
 }
 
 // privateChannelInfo queries access hash for the private channel.
-func (t *Telegram) privateChannelInfo(hash string) (id int64, access int64, err error) {
-	invite, err := t.client.API().MessagesCheckChatInvite(t.ctx, hash)
+func (t *Telegram) privateChannelInfo(ctx context.Context, hash string) (id int64, access int64, err error) {
+	invite, err := t.client.API().MessagesCheckChatInvite(ctx, hash)
 	if err != nil {
 		return
 	}
@@ -67,8 +68,8 @@ func (t *Telegram) privateChannelInfo(hash string) (id int64, access int64, err
 }
 
 // publicChannelInfo queries the public channel by its name.
-func (t *Telegram) publicChannelInfo(name string) (id, access int64, err error) {
-	username, err := t.client.API().ContactsResolveUsername(t.ctx, &tg.ContactsResolveUsernameRequest{Username: name})
+func (t *Telegram) publicChannelInfo(ctx context.Context, name string) (id, access int64, err error) {
+	username, err := t.client.API().ContactsResolveUsername(ctx, &tg.ContactsResolveUsernameRequest{Username: name})
 	if err != nil {
 		return
 	}
@@ -92,7 +93,7 @@ func (t *Telegram) publicChannelInfo(name string) (id, access int64, err error)
 }
 
 // queryLastMsgID from the given channel info.
-func (t *Telegram) queryLastMsgID(channelID, access int64) (int64, error) {
+func (t *Telegram) queryLastMsgID(ctx context.Context, channelID, access int64) (int64, error) {
 	request := &tg.MessagesSearchRequest{
 		Peer: &tg.InputPeerChannel{
 			ChannelID:  channelID,
@@ -105,7 +106,7 @@ func (t *Telegram) queryLastMsgID(channelID, access int64) (int64, error) {
 	}
 
 	last := -1
-	search, err := t.client.API().MessagesSearch(t.ctx, request)
+	search, err := t.client.API().MessagesSearch(ctx, request)
 	if err != nil {
 		return 0, err
 	}
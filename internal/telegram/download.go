@@ -1,6 +1,7 @@
 package telegram
 
 import (
+	"context"
 	"io"
 	"math"
 
@@ -10,20 +11,20 @@ import (
 	"github.com/bookstairs/bookhunter/internal/file"
 )
 
-func (t *Telegram) DownloadFile(f *File, writer io.Writer) error {
+func (t *Telegram) DownloadFile(ctx context.Context, f *File, writer io.Writer) error {
 	tool := downloader.NewDownloader()
 	thread := int(math.Ceil(float64(f.Size) / (512 * 1024)))
-	_, err := tool.Download(t.client.API(), f.Document).WithThreads(thread).Stream(t.ctx, writer)
+	_, err := tool.Download(t.client.API(), f.Document).WithThreads(thread).Stream(ctx, writer)
 
 	return err
 }
 
 // ParseMessage will parse the given message id.
-func (t *Telegram) ParseMessage(info *ChannelInfo, msgID int64) ([]File, error) {
+func (t *Telegram) ParseMessage(ctx context.Context, info *ChannelInfo, msgID int64) ([]File, error) {
 	var files []File
 	// This API is translated from official C++ client.
 	api := t.client.API()
-	history, err := api.MessagesSearch(t.ctx, &tg.MessagesSearchRequest{
+	history, err := api.MessagesSearch(ctx, &tg.MessagesSearchRequest{
 		Peer: &tg.InputPeerChannel{
 			ChannelID:  info.ID,
 			AccessHash: info.AccessHash,